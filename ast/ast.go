@@ -457,6 +457,9 @@ type DesugaredObjectField struct {
 	Name      Node
 	Body      Node
 	PlusSuper bool
+	// Computed is true if the name was not written as an identifier,
+	// e.g. ["b"]: 2 or "b": 2.
+	Computed bool
 }
 type DesugaredObjectFields []DesugaredObjectField
 
@@ -51,6 +51,8 @@ func (lr *LocationRange) IsSet() bool {
 	return lr.Begin.IsSet()
 }
 
+// String formats the range as file:line:column, file:line:column-column or,
+// for a range spanning several lines, file:line:column-line:column.
 func (lr *LocationRange) String() string {
 	if !lr.IsSet() {
 		return lr.FileName
@@ -67,7 +69,7 @@ func (lr *LocationRange) String() string {
 		return fmt.Sprintf("%s%v-%v", filePrefix, lr.Begin.String(), lr.End.Column)
 	}
 
-	return fmt.Sprintf("%s(%v)-(%v)", filePrefix, lr.Begin.String(), lr.End.String())
+	return fmt.Sprintf("%s%v-%v", filePrefix, lr.Begin.String(), lr.End.String())
 }
 
 // This is useful for special locations, e.g. manifestation entry point.
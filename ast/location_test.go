@@ -0,0 +1,38 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ast
+
+import "testing"
+
+func TestLocationRangeString(t *testing.T) {
+	tests := []struct {
+		name     string
+		lr       LocationRange
+		expected string
+	}{
+		{"point", MakeLocationRange("file", Location{2, 3}, Location{2, 3}), "file:2:3"},
+		{"singleLine", MakeLocationRange("file", Location{2, 3}, Location{2, 7}), "file:2:3-7"},
+		{"multiLine", MakeLocationRange("file", Location{2, 3}, Location{5, 7}), "file:2:3-5:7"},
+		{"noFileName", MakeLocationRange("", Location{2, 3}, Location{5, 7}), "2:3-5:7"},
+		{"message", MakeLocationRangeMessage("<builtin>"), "<builtin>"},
+	}
+	for _, test := range tests {
+		if actual := test.lr.String(); actual != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, actual)
+		}
+	}
+}
@@ -19,12 +19,21 @@ package jsonnet
 import (
 	"bytes"
 	"crypto/md5"
+	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
 	"math"
-	"sort"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/google/go-jsonnet/ast"
+	"gopkg.in/yaml.v3"
 )
 
 // TODO(sbarzowski) Is this the best option? It's the first one that worked for me...
@@ -60,7 +69,7 @@ func builtinPlus(e *evaluator, xp, yp potentialValue) (value, error) {
 		if err != nil {
 			return nil, err
 		}
-		return makeValueNumber(left.value + right.value), nil
+		return makeDoubleCheck(e, left.value+right.value)
 	case *valueString:
 		right, err := builtinToString(e, yp)
 		if err != nil {
@@ -93,7 +102,7 @@ func builtinMinus(e *evaluator, xp, yp potentialValue) (value, error) {
 	if err != nil {
 		return nil, err
 	}
-	return makeValueNumber(x.value - y.value), nil
+	return makeDoubleCheck(e, x.value-y.value)
 }
 
 func builtinMult(e *evaluator, xp, yp potentialValue) (value, error) {
@@ -105,7 +114,7 @@ func builtinMult(e *evaluator, xp, yp potentialValue) (value, error) {
 	if err != nil {
 		return nil, err
 	}
-	return makeValueNumber(x.value * y.value), nil
+	return makeDoubleCheck(e, x.value*y.value)
 }
 
 func builtinDiv(e *evaluator, xp, yp potentialValue) (value, error) {
@@ -138,6 +147,49 @@ func builtinModulo(e *evaluator, xp, yp potentialValue) (value, error) {
 	return makeDoubleCheck(e, math.Mod(x.value, y.value))
 }
 
+// builtinMod implements the % operator, which is modulo for numbers and
+// formatting (std.format) for strings.
+func builtinMod(e *evaluator, xp, yp potentialValue) (value, error) {
+	x, err := e.evaluate(xp)
+	if err != nil {
+		return nil, err
+	}
+	switch x.(type) {
+	case *valueNumber:
+		y, err := e.evaluate(yp)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := y.(*valueNumber); !ok {
+			return nil, modTypeError(e, x, y)
+		}
+		return builtinModulo(e, &readyValue{x}, &readyValue{y})
+	case *valueString:
+		std, err := e.evaluateObject(e.i.initialEnv.upValues["std"])
+		if err != nil {
+			return nil, err
+		}
+		formatp, err := std.index(e, "format")
+		if err != nil {
+			return nil, err
+		}
+		format, err := e.getFunction(formatp)
+		if err != nil {
+			return nil, err
+		}
+		return e.evaluate(format.call(args(&readyValue{x}, yp)))
+	}
+	y, err := e.evaluate(yp)
+	if err != nil {
+		return nil, err
+	}
+	return nil, modTypeError(e, x, y)
+}
+
+func modTypeError(e *evaluator, x, y value) error {
+	return e.Error(fmt.Sprintf("Operator %% cannot be used on types %v and %v.", x.typename(), y.typename()))
+}
+
 func builtinLess(e *evaluator, xp, yp potentialValue) (value, error) {
 	x, err := e.evaluate(xp)
 	if err != nil {
@@ -241,229 +293,1345 @@ func builtinToString(e *evaluator, xp potentialValue) (value, error) {
 	case *valueString:
 		return x, nil
 	}
-	var buf bytes.Buffer
-	err = e.i.manifestJSON(e.trace, x, false, "", &buf)
+	var buf manifestBuffer
+	err = e.i.manifestJSON(e.trace, x, singleLineJSON, "", nil, &buf)
 	if err != nil {
 		return nil, err
 	}
 	return makeValueString(buf.String()), nil
 }
 
-func builtinMakeArray(e *evaluator, szp potentialValue, funcp potentialValue) (value, error) {
-	sz, err := e.evaluateNumber(szp)
-	if err != nil {
-		return nil, err
+// jsonErrorContext is the number of bytes of input shown on each side of the
+// position of a JSON syntax error.
+const jsonErrorContext = 10
+
+// describeJSONPosition describes the position pos in input, along with the
+// input around it, for error messages.
+func describeJSONPosition(input []byte, pos int) string {
+	line, column := 1, 1
+	for _, c := range string(input[:pos]) {
+		if c == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
 	}
-	fun, err := e.evaluateFunction(funcp)
-	if err != nil {
-		return nil, err
+	from, to := pos-jsonErrorContext, pos+jsonErrorContext
+	if from < 0 {
+		from = 0
 	}
-	num := int(sz.value)
-	var elems []potentialValue
-	for i := 0; i < num; i++ {
-		elem := fun.call(args(&readyValue{intToValue(i)}))
-		elems = append(elems, elem)
+	if to > len(input) {
+		to = len(input)
 	}
-	return makeValueArray(elems), nil
+	// Don't split multibyte characters.
+	for from > 0 && !utf8.RuneStart(input[from]) {
+		from--
+	}
+	for to < len(input) && !utf8.RuneStart(input[to]) {
+		to++
+	}
+	return fmt.Sprintf("at offset %d (line %d, column %d), near %s",
+		pos, line, column, unparseString(string(input[from:to])))
 }
 
-func builtinFlatMap(e *evaluator, funcp potentialValue, arrp potentialValue) (value, error) {
-	arr, err := e.evaluateArray(arrp)
-	if err != nil {
-		return nil, err
+// jsonErrorPosition returns the position in input of an error returned by
+// the JSON decoder.
+func jsonErrorPosition(input []byte, err error) (int, bool) {
+	switch err := err.(type) {
+	case *json.SyntaxError:
+		// The decoder reports the offset after the offending byte.
+		if err.Offset > 0 {
+			return int(err.Offset) - 1, true
+		}
+		return 0, true
+	case *json.UnmarshalTypeError:
+		return int(err.Offset), true
 	}
-	fun, err := e.evaluateFunction(funcp)
-	if err != nil {
-		return nil, err
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return len(input), true
 	}
-	num := int(arr.length())
-	// Start with capacity of the original array.
-	// This may spare us a few reallocations.
-	// TODO(sbarzowski) verify that it actually helps
-	elems := make([]potentialValue, 0, num)
-	for i := 0; i < num; i++ {
-		returned, err := e.evaluateArray(fun.call(args(arr.elements[i])))
+	return 0, false
+}
+
+// exactJSONNumbers replaces the json.Numbers in data decoded with UseNumber
+// by float64s. It fails on integers which a float64 can't represent exactly,
+// e.g. 9007199254740993 (2^53 + 1), instead of rounding them.
+func exactJSONNumbers(data interface{}) (interface{}, error) {
+	switch data := data.(type) {
+	case json.Number:
+		f, err := strconv.ParseFloat(string(data), 64)
 		if err != nil {
 			return nil, err
 		}
-		for _, elem := range returned.elements {
-			elems = append(elems, elem)
+		if exact, ok := new(big.Int).SetString(string(data), 10); ok {
+			rounded, _ := big.NewFloat(f).Int(nil)
+			if rounded.Cmp(exact) != 0 {
+				return nil, fmt.Errorf("integer %v can't be represented exactly as a number, the nearest is %v", data, unparseNumber(f))
+			}
+		}
+		return f, nil
+	case []interface{}:
+		for i, elem := range data {
+			var err error
+			if data[i], err = exactJSONNumbers(elem); err != nil {
+				return nil, err
+			}
+		}
+	case map[string]interface{}:
+		for key, fieldValue := range data {
+			var err error
+			if data[key], err = exactJSONNumbers(fieldValue); err != nil {
+				return nil, err
+			}
 		}
 	}
-	return makeValueArray(elems), nil
+	return data, nil
 }
 
-func builtinFilter(e *evaluator, funcp potentialValue, arrp potentialValue) (value, error) {
-	arr, err := e.evaluateArray(arrp)
+func builtinParseJSON(e *evaluator, strp potentialValue) (value, error) {
+	str, err := e.evaluateString(strp)
 	if err != nil {
 		return nil, err
 	}
-	fun, err := e.evaluateFunction(funcp)
+	input := []byte(str.getString())
+	decoder := json.NewDecoder(bytes.NewReader(input))
+	exactIntegers := e.i.evalOpts.exactJSONIntegers
+	if exactIntegers {
+		decoder.UseNumber()
+	}
+	var parsed interface{}
+	err = decoder.Decode(&parsed)
 	if err != nil {
-		return nil, err
+		msg := err.Error()
+		if err == io.EOF {
+			msg = "unexpected end of JSON input"
+		}
+		if pos, ok := jsonErrorPosition(input, err); ok {
+			msg += " " + describeJSONPosition(input, pos)
+		}
+		return nil, e.Error("Failed to parse JSON: " + msg)
 	}
-	num := int(arr.length())
-	// Start with capacity of the original array.
-	// This may spare us a few reallocations.
-	// TODO(sbarzowski) verify that it actually helps
-	elems := make([]potentialValue, 0, num)
-	for i := 0; i < num; i++ {
-		included, err := e.evaluateBoolean(fun.call(args(arr.elements[i])))
+	// Only whitespace may follow the value.
+	rest := decoder.InputOffset()
+	for rest < int64(len(input)) && strings.ContainsRune(" \t\r\n", rune(input[rest])) {
+		rest++
+	}
+	if rest < int64(len(input)) {
+		return nil, e.Error("Failed to parse JSON: unexpected data after the value " +
+			describeJSONPosition(input, int(rest)))
+	}
+	if exactIntegers {
+		parsed, err = exactJSONNumbers(parsed)
 		if err != nil {
-			return nil, err
+			return nil, e.Error("Failed to parse JSON: " + err.Error())
 		}
-		if included.value {
-			elems = append(elems, arr.elements[i])
+	}
+	result, err := valueFromGo(parsed)
+	if err != nil {
+		return nil, e.Error(err.Error())
+	}
+	return result, nil
+}
+
+// yamlConverter converts a parsed YAML document to the form expected by
+// valueFromGo. Scalars are resolved by the YAML 1.2 core schema, which is a
+// superset of JSON: only true and false are booleans (yes, no, on and off
+// stay strings), null and ~ are null, integers and floats become numbers and
+// quoted scalars are always strings. Object keys are taken as written, so
+// 1: a has the key "1".
+//
+// Aliases are expanded into copies of the anchored values. A value which
+// contains an alias to itself is rejected, and so are documents which mostly
+// consist of expanded aliases, like yaml.v3 does when decoding, because a
+// small document could otherwise expand to billions of values.
+type yamlConverter struct {
+	// The anchored nodes whose aliases are being expanded.
+	expanding map[*yaml.Node]bool
+	// The number of nodes converted, and how many of them were reached
+	// through an alias.
+	nodes      int
+	aliasNodes int
+}
+
+// yamlDecimalNumber matches the plain scalars which are decimal numbers in
+// the YAML 1.2 core schema. yamlOtherNumber matches the rest of its numbers,
+// including the infinities and NaN, which are then rejected.
+var yamlDecimalNumber = regexp.MustCompile(`^[-+]?(?:\.[0-9]+|[0-9]+(?:\.[0-9]*)?)(?:[eE][-+]?[0-9]+)?$`)
+var yamlOtherNumber = regexp.MustCompile(`^(?:0o[0-7]+|0x[0-9a-fA-F]+|[-+]?\.(?:inf|Inf|INF)|\.(?:nan|NaN|NAN))$`)
+
+// yamlAliasRatio is the largest allowed ratio of nodes reached through
+// aliases to all nodes, given the number of nodes. It is the same as in
+// yaml.v3: documents of up to 400000 nodes may consist almost only of
+// aliases, larger ones gradually less, down to 10% from 4000000 nodes on.
+func yamlAliasRatio(nodes int) float64 {
+	const low, high = 400000, 4000000
+	switch {
+	case nodes <= low:
+		return 0.99
+	case nodes >= high:
+		return 0.10
+	}
+	return 0.99 - 0.89*float64(nodes-low)/float64(high-low)
+}
+
+func (c *yamlConverter) convert(node *yaml.Node) (interface{}, error) {
+	c.nodes++
+	if len(c.expanding) > 0 {
+		c.aliasNodes++
+	}
+	if c.aliasNodes > 100 && c.nodes > 1000 && float64(c.aliasNodes)/float64(c.nodes) > yamlAliasRatio(c.nodes) {
+		return nil, fmt.Errorf("document contains excessive aliasing")
+	}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return c.convert(node.Content[0])
+	case yaml.AliasNode:
+		if c.expanding[node.Alias] {
+			return nil, fmt.Errorf("line %d: alias *%s refers to a value which contains it", node.Line, node.Value)
+		}
+		c.expanding[node.Alias] = true
+		defer delete(c.expanding, node.Alias)
+		return c.convert(node.Alias)
+	case yaml.SequenceNode:
+		elems := make([]interface{}, 0, len(node.Content))
+		for _, elemNode := range node.Content {
+			elem, err := c.convert(elemNode)
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, elem)
+		}
+		return elems, nil
+	case yaml.MappingNode:
+		fields := make(map[string]interface{}, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode := node.Content[i]
+			if keyNode.Kind == yaml.AliasNode {
+				keyNode = keyNode.Alias
+			}
+			if keyNode.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("line %d: object keys must be scalars", keyNode.Line)
+			}
+			if _, ok := fields[keyNode.Value]; ok {
+				return nil, fmt.Errorf("line %d: duplicate key %s", keyNode.Line, unparseString(keyNode.Value))
+			}
+			fieldValue, err := c.convert(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			fields[keyNode.Value] = fieldValue
+		}
+		return fields, nil
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!null":
+			return nil, nil
+		case "!!bool":
+			var b bool
+			err := node.Decode(&b)
+			return b, err
+		case "!!int", "!!float":
+			var f float64
+			switch {
+			case yamlDecimalNumber.MatchString(node.Value):
+				// yaml.v3 reads 010 as a YAML 1.1 octal number, while it is
+				// 10 in the core schema. The syntax is already checked,
+				// numbers out of range come back as infinities.
+				f, _ = strconv.ParseFloat(node.Value, 64)
+			case node.Style&yaml.TaggedStyle != 0 || yamlOtherNumber.MatchString(node.Value):
+				if err := node.Decode(&f); err != nil {
+					return nil, err
+				}
+			default:
+				// yaml.v3 also takes YAML 1.1 forms like 1_000 and 0b11
+				// for numbers.
+				return node.Value, nil
+			}
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				return nil, fmt.Errorf("line %d: %s is not a valid number", node.Line, node.Value)
+			}
+			return f, nil
 		}
+		return node.Value, nil
 	}
-	return makeValueArray(elems), nil
+	return nil, fmt.Errorf("line %d: unsupported YAML node", node.Line)
 }
 
-func builtinNegation(e *evaluator, xp potentialValue) (value, error) {
-	x, err := e.evaluateBoolean(xp)
+// builtinParseYAML parses a single YAML document. Streams of several
+// documents, separated by ---, are rejected rather than silently cut to
+// the first document.
+func builtinParseYAML(e *evaluator, strp potentialValue) (value, error) {
+	str, err := e.evaluateString(strp)
 	if err != nil {
 		return nil, err
 	}
-	return makeValueBoolean(!x.value), nil
+	decoder := yaml.NewDecoder(strings.NewReader(str.getString()))
+	var document yaml.Node
+	err = decoder.Decode(&document)
+	if err == io.EOF {
+		// Empty input, there is no document at all.
+		return makeValueNull(), nil
+	}
+	if err != nil {
+		return nil, e.Error("Failed to parse YAML: " + err.Error())
+	}
+	var next yaml.Node
+	if err := decoder.Decode(&next); err != io.EOF {
+		if err != nil {
+			return nil, e.Error("Failed to parse YAML: " + err.Error())
+		}
+		return nil, e.Error(fmt.Sprintf("Failed to parse YAML: line %d: expected a single document, got several", next.Line))
+	}
+	converter := &yamlConverter{expanding: make(map[*yaml.Node]bool)}
+	parsed, err := converter.convert(&document)
+	if err != nil {
+		return nil, e.Error("Failed to parse YAML: " + err.Error())
+	}
+	result, err := valueFromGo(parsed)
+	if err != nil {
+		return nil, e.Error(err.Error())
+	}
+	return result, nil
 }
 
-func builtinBitNeg(e *evaluator, xp potentialValue) (value, error) {
-	x, err := e.evaluateNumber(xp)
+// builtinManifestJSONEx implements std.manifestJsonEx(value, indent,
+// compactArrayWidth, preserveOrder, sortKeys, decimalPlaces, replacer).
+// sortKeys defaults to null. A boolean sortKeys sets the order of the keys
+// and takes precedence over preserveOrder.
+func builtinManifestJSONEx(e *evaluator, args []potentialValue) (value, error) {
+	x, err := e.evaluate(args[0])
 	if err != nil {
 		return nil, err
 	}
-	i := int64(x.value)
-	return int64ToValue(^i), nil
-}
-
-func builtinIdentity(e *evaluator, xp potentialValue) (value, error) {
-	x, err := e.evaluate(xp)
+	indent, err := e.evaluateString(args[1])
 	if err != nil {
 		return nil, err
 	}
-	return x, nil
-}
-
-func builtinUnaryMinus(e *evaluator, xp potentialValue) (value, error) {
-	x, err := e.evaluateNumber(xp)
+	compactArrayWidth, err := e.evaluateNumber(args[2])
 	if err != nil {
 		return nil, err
 	}
-	return makeValueNumber(-x.value), nil
-}
-
-func primitiveEquals(e *evaluator, xp potentialValue, yp potentialValue) (value, error) {
-	x, err := e.evaluate(xp)
+	width := compactArrayWidth.value
+	if width < 0 || width != math.Trunc(width) {
+		return nil, e.Error(fmt.Sprintf("std.manifestJsonEx compactArrayWidth should be a non-negative integer, got %v", width))
+	}
+	// No array is written wider than MaxInt32 characters, so larger widths
+	// are the same as MaxInt32.
+	width = math.Min(width, math.MaxInt32)
+	preserveOrder, err := e.evaluateBoolean(args[3])
 	if err != nil {
 		return nil, err
 	}
-	y, err := e.evaluate(yp)
+	sortKeys, err := e.evaluate(args[4])
 	if err != nil {
 		return nil, err
 	}
-	if x.typename() != y.typename() { // TODO(sbarzowski) ugh, string comparison
-		return makeValueBoolean(false), nil
+	decimalPlaces, err := e.evaluate(args[5])
+	if err != nil {
+		return nil, err
 	}
-	switch left := x.(type) {
+	replacer, err := e.evaluate(args[6])
+	if err != nil {
+		return nil, err
+	}
+	opts := &manifestJSONOptions{
+		multiline:         true,
+		indent:            indent.getString(),
+		compactArrayWidth: int(width),
+		preserveOrder:     preserveOrder.value,
+		tightEmpty:        true,
+	}
+	switch sortKeys := sortKeys.(type) {
+	case *valueNull:
+		// The order is up to preserveOrder.
 	case *valueBoolean:
-		right, err := e.getBoolean(y)
-		if err != nil {
-			return nil, err
-		}
-		return makeValueBoolean(left.value == right.value), nil
+		opts.preserveOrder = !sortKeys.value
+	default:
+		return nil, e.Error(fmt.Sprintf("std.manifestJsonEx sortKeys should be a boolean or null, got %s", sortKeys.typename()))
+	}
+	switch decimalPlaces := decimalPlaces.(type) {
+	case *valueNull:
+		// Numbers are written as usual.
 	case *valueNumber:
-		right, err := e.getNumber(y)
-		if err != nil {
-			return nil, err
+		places := decimalPlaces.value
+		if places < 0 || places > 20 || places != math.Floor(places) {
+			return nil, e.Error(fmt.Sprintf("std.manifestJsonEx decimalPlaces should be an integer from 0 to 20, got %v", places))
 		}
-		return makeValueBoolean(left.value == right.value), nil
-	case *valueString:
-		right, err := e.getString(y)
+		opts.fixedDecimals = true
+		opts.decimalPlaces = int(places)
+	default:
+		return nil, e.Error(fmt.Sprintf("std.manifestJsonEx decimalPlaces should be a number or null, got %s", decimalPlaces.typename()))
+	}
+	switch replacer := replacer.(type) {
+	case *valueNull:
+		// Values are written as they are.
+	case *valueFunction:
+		opts.replacer = replacer
+		opts.replaced = make(map[replacedKey]replacedValue)
+		// Like in JSON.stringify, the top-level value is passed with an
+		// empty key.
+		var omitted bool
+		x, omitted, err = e.i.replaceManifested(e.trace, opts, replacedKey{}, x)
 		if err != nil {
 			return nil, err
 		}
-		return makeValueBoolean(stringEqual(left, right)), nil
-	case *valueNull:
-		return makeValueBoolean(true), nil
-	case *valueFunction:
-		return nil, e.Error("Cannot test equality of functions")
+		if omitted {
+			return nil, e.Error("std.manifestJsonEx replacer cannot omit the top-level value")
+		}
 	default:
-		return nil, e.Error(
-			"primitiveEquals operates on primitive types, got " + x.typename(),
-		)
+		return nil, e.Error(fmt.Sprintf("std.manifestJsonEx replacer should be a function or null, got %s", replacer.typename()))
 	}
-}
-
-func builtinType(e *evaluator, xp potentialValue) (value, error) {
-	x, err := e.evaluate(xp)
+	var buf manifestBuffer
+	err = e.i.manifestJSON(e.trace, x, opts, "", nil, &buf)
 	if err != nil {
 		return nil, err
 	}
-	return makeValueString(x.typename()), nil
+	return makeValueString(buf.String()), nil
 }
 
-func builtinMd5(e *evaluator, xp potentialValue) (value, error) {
-	x, err := e.evaluateString(xp)
+// builtinManifestYamlDoc implements std.manifestYamlDoc(value,
+// indent_array_in_object, preserveOrder). The first two parameters are the
+// same as upstream, preserveOrder is an extension.
+func builtinManifestYamlDoc(e *evaluator, args []potentialValue) (value, error) {
+	x, err := e.evaluate(args[0])
 	if err != nil {
 		return nil, err
 	}
-	hash := md5.Sum([]byte(string(x.value)))
-	return makeValueString(hex.EncodeToString(hash[:])), nil
-}
-
-// Maximum allowed unicode codepoint
-// https://en.wikipedia.org/wiki/Unicode#Architecture_and_terminology
-const codepointMax = 0x10FFFF
-
-func builtinChar(e *evaluator, xp potentialValue) (value, error) {
-	x, err := e.evaluateNumber(xp)
+	indentArrayInObject, err := e.evaluateBoolean(args[1])
 	if err != nil {
 		return nil, err
 	}
-	if x.value > codepointMax {
-		return nil, e.Error(fmt.Sprintf("Invalid unicode codepoint, got %v", x.value))
-	} else if x.value < 0 {
-		return nil, e.Error(fmt.Sprintf("Codepoints must be >= 0, got %v", x.value))
+	preserveOrder, err := e.evaluateBoolean(args[2])
+	if err != nil {
+		return nil, err
 	}
-	return makeValueString(string(rune(x.value))), nil
+	opts := &manifestYAMLOptions{
+		preserveOrder:       preserveOrder.value,
+		indentArrayInObject: indentArrayInObject.value,
+	}
+	var buf bytes.Buffer
+	err = e.i.manifestYAML(e.trace, x, yamlTopLevel, "", opts, nil, &buf)
+	if err != nil {
+		return nil, err
+	}
+	return makeValueString(buf.String()), nil
 }
 
-func builtinCodepoint(e *evaluator, xp potentialValue) (value, error) {
-	x, err := e.evaluateString(xp)
+func builtinManifestXMLJsonml(e *evaluator, xp potentialValue) (value, error) {
+	x, err := e.evaluateArray(xp)
 	if err != nil {
 		return nil, err
 	}
-	if x.length() != 1 {
-		return nil, e.Error(fmt.Sprintf("codepoint takes a string of length 1, got length %v", x.length()))
+	var buf bytes.Buffer
+	err = e.i.manifestXMLJsonml(e.trace, x, nil, &buf)
+	if err != nil {
+		return nil, err
 	}
-	return makeValueNumber(float64(x.value[0])), nil
+	return makeValueString(buf.String()), nil
 }
 
-func makeDoubleCheck(e *evaluator, x float64) (value, error) {
-	if math.IsNaN(x) {
-		return nil, e.Error("Not a number")
+func builtinMakeArray(e *evaluator, szp potentialValue, funcp potentialValue) (value, error) {
+	sz, err := e.evaluateNumber(szp)
+	if err != nil {
+		return nil, err
 	}
-	if math.IsInf(x, 0) {
-		return nil, e.Error("Overflow")
+	fun, err := e.evaluateFunction(funcp)
+	if err != nil {
+		return nil, err
 	}
-	return makeValueNumber(x), nil
+	num := int(sz.value)
+	var elems []potentialValue
+	for i := 0; i < num; i++ {
+		elem := fun.call(args(&readyValue{intToValue(i)}))
+		elems = append(elems, elem)
+	}
+	return makeValueArray(elems), nil
 }
 
-func liftNumeric(f func(float64) float64) func(*evaluator, potentialValue) (value, error) {
-	return func(e *evaluator, xp potentialValue) (value, error) {
-		x, err := e.evaluateNumber(xp)
+// builtinTrace writes str to the trace output, with the location loc of the
+// call, and returns rest. If tracing is disabled, str is not even evaluated.
+func builtinTrace(e *evaluator, strp potentialValue, restp potentialValue, loc *ast.LocationRange) (value, error) {
+	out := e.i.evalOpts.traceOut
+	if out == nil {
+		return e.evaluate(restp)
+	}
+	str, err := e.evaluateString(strp)
+	if err != nil {
+		return nil, err
+	}
+	if e.i.evalOpts.traceJSON {
+		msg, err := json.Marshal(struct {
+			File    string `json:"file"`
+			Line    int    `json:"line"`
+			Message string `json:"message"`
+		}{loc.FileName, loc.Begin.Line, str.getString()})
 		if err != nil {
 			return nil, err
 		}
-		return makeDoubleCheck(e, f(x.value))
+		fmt.Fprintf(out, "%s\n", msg)
+	} else {
+		var indent string
+		if e.i.evalOpts.traceIndent {
+			indent = strings.Repeat("  ", e.i.stack.calls)
+		}
+		fmt.Fprintf(out, "%sTRACE: %s:%d %s\n", indent, loc.FileName, loc.Begin.Line, str.getString())
 	}
+	return e.evaluate(restp)
 }
 
-var builtinSqrt = liftNumeric(math.Sqrt)
-var builtinCeil = liftNumeric(math.Ceil)
-var builtinFloor = liftNumeric(math.Floor)
-var builtinSin = liftNumeric(math.Sin)
-var builtinCos = liftNumeric(math.Cos)
+// builtinCoalesce returns the first element of arr which is not null, or null.
+// The elements after it are not evaluated.
+func builtinCoalesce(e *evaluator, arrp potentialValue) (value, error) {
+	arr, err := e.evaluateArray(arrp)
+	if err != nil {
+		return nil, err
+	}
+	for _, elemp := range arr.elements {
+		elem, err := e.evaluate(elemp)
+		if err != nil {
+			return nil, err
+		}
+		if _, isNull := elem.(*valueNull); !isNull {
+			return elem, nil
+		}
+	}
+	return makeValueNull(), nil
+}
+
+// builtinMakeObject builds an object with the given keys, in that order.
+// The values are computed lazily, by calling fun with the key.
+func builtinMakeObject(e *evaluator, keysp potentialValue, funcp potentialValue) (value, error) {
+	keys, err := e.evaluateArray(keysp)
+	if err != nil {
+		return nil, err
+	}
+	fun, err := e.evaluateFunction(funcp)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(valueSimpleObjectFieldMap, len(keys.elements))
+	for i, keyp := range keys.elements {
+		keyValue, err := e.evaluate(keyp)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := keyValue.(*valueString)
+		if !ok {
+			return nil, e.Error(fmt.Sprintf("makeObject expects an array of strings, got %v at index %d", keyValue.typename(), i))
+		}
+		if _, duplicate := fields[key.getString()]; duplicate {
+			return nil, e.Error(fmt.Sprintf("makeObject got duplicate key %v at index %d", unparseString(key.getString()), i))
+		}
+		fieldp := fun.call(args(&readyValue{key}))
+		fields[key.getString()] = valueSimpleObjectField{
+			hide:  ast.ObjectFieldInherit,
+			field: &potentialValueUnboundField{fieldp},
+			order: len(fields),
+		}
+	}
+	return makeValueSimpleObject(nil, fields, nil), nil
+}
+
+// rangeLength returns the number of elements of a range covering distance,
+// which is not negative, with the given positive step. The elements are
+// allocated up front, so ranges longer than the VM allows are rejected.
+func rangeLength(e *evaluator, builtin string, distance, step float64) (int, error) {
+	num := math.Floor(distance/step) + 1
+	if math.IsInf(num, 0) || math.IsNaN(num) || num >= math.MaxInt64 {
+		return 0, e.Error(fmt.Sprintf("std.%s would produce too many elements", builtin))
+	}
+	if limit := e.i.evalOpts.maxRangeLength; limit > 0 && num > float64(limit) {
+		return 0, e.Error(fmt.Sprintf("std.%s would produce too many elements (more than %d)", builtin, limit))
+	}
+	return int(num), nil
+}
+
+func builtinRange(e *evaluator, args []potentialValue) (value, error) {
+	from, err := e.evaluateNumber(args[0])
+	if err != nil {
+		return nil, err
+	}
+	to, err := e.evaluateNumber(args[1])
+	if err != nil {
+		return nil, err
+	}
+	step, err := e.evaluateNumber(args[2])
+	if err != nil {
+		return nil, err
+	}
+	if step.value <= 0 {
+		return nil, e.Error(fmt.Sprintf("range step must be positive, got %v", unparseNumber(step.value)))
+	}
+	var elems []potentialValue
+	if to.value >= from.value {
+		num, err := rangeLength(e, "range", to.value-from.value, step.value)
+		if err != nil {
+			return nil, err
+		}
+		elems = make([]potentialValue, num)
+		for i := range elems {
+			elems[i] = &readyValue{makeValueNumber(from.value + float64(i)*step.value)}
+		}
+	}
+	return makeValueArray(elems), nil
+}
+
+// builtinReverseRange is the descending counterpart of std.range. Both ends
+// are inclusive, like in std.range, so std.reverseRange(5, 1) is
+// [5, 4, 3, 2, 1] and the result is empty if to is greater than from.
+func builtinReverseRange(e *evaluator, args []potentialValue) (value, error) {
+	from, err := e.evaluateNumber(args[0])
+	if err != nil {
+		return nil, err
+	}
+	to, err := e.evaluateNumber(args[1])
+	if err != nil {
+		return nil, err
+	}
+	step, err := e.evaluateNumber(args[2])
+	if err != nil {
+		return nil, err
+	}
+	if step.value <= 0 {
+		return nil, e.Error(fmt.Sprintf("reverseRange step must be positive, got %v", unparseNumber(step.value)))
+	}
+	var elems []potentialValue
+	if from.value >= to.value {
+		num, err := rangeLength(e, "reverseRange", from.value-to.value, step.value)
+		if err != nil {
+			return nil, err
+		}
+		elems = make([]potentialValue, num)
+		for i := range elems {
+			elems[i] = &readyValue{makeValueNumber(from.value - float64(i)*step.value)}
+		}
+	}
+	return makeValueArray(elems), nil
+}
+
+// sliceIndex evaluates an optional slice index or step, which defaults to def.
+// Negative indices count from the end of the length, if the VM allows them.
+// Fractions and numbers out of the range of int64 are rejected, rather than
+// silently converted.
+func sliceIndex(e *evaluator, indexp potentialValue, name string, def, length int, isIndex bool) (int, error) {
+	v, err := e.evaluate(indexp)
+	if err != nil {
+		return 0, err
+	}
+	if _, isNull := v.(*valueNull); isNull {
+		return def, nil
+	}
+	num, err := e.getNumber(v)
+	if err != nil {
+		return 0, err
+	}
+	if num.value != math.Trunc(num.value) || num.value < math.MinInt64 || num.value >= math.MaxInt64 {
+		return 0, e.Error(fmt.Sprintf("got %v but %s must be a 64-bit integer", num.value, name))
+	}
+	index := int(num.value)
+	if isIndex && index < 0 && e.i.evalOpts.negativeSliceIndices {
+		index += length
+		if index < 0 {
+			index = 0
+		}
+	}
+	return index, nil
+}
+
+func builtinSlice(e *evaluator, args []potentialValue) (value, error) {
+	indexable, err := e.evaluate(args[0])
+	if err != nil {
+		return nil, err
+	}
+	var length int
+	switch indexable := indexable.(type) {
+	case *valueString:
+		length = indexable.length()
+	case *valueArray:
+		length = indexable.length()
+	default:
+		return nil, e.Error(fmt.Sprintf("std.slice accepts a string or an array, but got: %s", indexable.typename()))
+	}
+	index, err := sliceIndex(e, args[1], "index", 0, length, true)
+	if err != nil {
+		return nil, err
+	}
+	end, err := sliceIndex(e, args[2], "end", length, length, true)
+	if err != nil {
+		return nil, err
+	}
+	step, err := sliceIndex(e, args[3], "step", 1, length, false)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || end < 0 || step < 0 {
+		return nil, e.Error(fmt.Sprintf("got [%d:%d:%d] but negative index, end, and steps are not supported", index, end, step))
+	}
+	if step == 0 {
+		return nil, e.Error(fmt.Sprintf("got %d but step must be greater than 0", step))
+	}
+	if end > length {
+		end = length
+	}
+	// Counting the elements first, index + k*step stays below end, while
+	// adding a huge step to the index could overflow.
+	num := 0
+	if index < end {
+		num = (end-index-1)/step + 1
+	}
+	switch indexable := indexable.(type) {
+	case *valueString:
+		var result []rune
+		for k := 0; k < num; k++ {
+			result = append(result, indexable.value[index+k*step])
+		}
+		return &valueString{value: result}, nil
+	default:
+		var elems []potentialValue
+		for k := 0; k < num; k++ {
+			elems = append(elems, indexable.(*valueArray).elements[index+k*step])
+		}
+		return makeValueArray(elems), nil
+	}
+}
+
+func builtinFlatMap(e *evaluator, funcp potentialValue, arrp potentialValue) (value, error) {
+	arr, err := e.evaluateArray(arrp)
+	if err != nil {
+		return nil, err
+	}
+	fun, err := e.evaluateFunction(funcp)
+	if err != nil {
+		return nil, err
+	}
+	num := int(arr.length())
+	// Start with capacity of the original array.
+	// This may spare us a few reallocations.
+	// TODO(sbarzowski) verify that it actually helps
+	elems := make([]potentialValue, 0, num)
+	for i := 0; i < num; i++ {
+		returned, err := e.evaluateArray(fun.call(args(arr.elements[i])))
+		if err != nil {
+			return nil, err
+		}
+		for _, elem := range returned.elements {
+			elems = append(elems, elem)
+		}
+	}
+	return makeValueArray(elems), nil
+}
+
+// withElementIndex adds the index of the element that a builtin was
+// processing to an error, so that a bad element can be found in a long array.
+// Only the innermost fold adds its index.
+func withElementIndex(err error, builtin string, index int) error {
+	if rte, ok := err.(RuntimeError); ok && !rte.hasElementIndex {
+		rte.Msg += fmt.Sprintf(" (std.%s, at index %d)", builtin, index)
+		rte.hasElementIndex = true
+		return rte
+	}
+	return err
+}
+
+// builtinFoldl and builtinFoldr evaluate the accumulator after every step,
+// like the tailstrict calls they replace, so long arrays don't build up
+// deeply nested thunks. If reportIndex is set, errors say at which element
+// the fold failed. It is not set when the standard library folds, as its
+// internal indices would only confuse the user.
+func builtinFoldl(e *evaluator, funcp potentialValue, arrp potentialValue, initp potentialValue, reportIndex bool) (value, error) {
+	fun, err := e.evaluateFunction(funcp)
+	if err != nil {
+		return nil, err
+	}
+	arr, err := e.evaluateArray(arrp)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := e.evaluate(initp)
+	if err != nil {
+		return nil, err
+	}
+	for i, elem := range arr.elements {
+		acc, err = e.evaluate(fun.call(args(&readyValue{acc}, elem)))
+		if err != nil {
+			if reportIndex {
+				err = withElementIndex(err, "foldl", i)
+			}
+			return nil, err
+		}
+	}
+	return acc, nil
+}
+
+func builtinFoldr(e *evaluator, funcp potentialValue, arrp potentialValue, initp potentialValue, reportIndex bool) (value, error) {
+	fun, err := e.evaluateFunction(funcp)
+	if err != nil {
+		return nil, err
+	}
+	arr, err := e.evaluateArray(arrp)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := e.evaluate(initp)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(arr.elements) - 1; i >= 0; i-- {
+		acc, err = e.evaluate(fun.call(args(arr.elements[i], &readyValue{acc})))
+		if err != nil {
+			if reportIndex {
+				err = withElementIndex(err, "foldr", i)
+			}
+			return nil, err
+		}
+	}
+	return acc, nil
+}
+
+// builtinFoldUntil folds arr from the left like std.foldl, but func returns
+// {done, value}. It stops as soon as done is true, so the remaining elements
+// are never evaluated.
+func builtinFoldUntil(e *evaluator, funcp potentialValue, arrp potentialValue, initp potentialValue) (value, error) {
+	fun, err := e.evaluateFunction(funcp)
+	if err != nil {
+		return nil, err
+	}
+	arr, err := e.evaluateArray(arrp)
+	if err != nil {
+		return nil, err
+	}
+	acc := initp
+	for _, elem := range arr.elements {
+		result, err := e.evaluateObject(fun.call(args(acc, elem)))
+		if err != nil {
+			return nil, err
+		}
+		doneValue, err := result.index(e, "done")
+		if err != nil {
+			return nil, err
+		}
+		done, err := e.getBoolean(doneValue)
+		if err != nil {
+			return nil, err
+		}
+		valuep := tryObjectIndex(objectBinding(result), "value", withHidden)
+		if valuep == nil {
+			return nil, e.Error("foldUntil function must return an object with a value field")
+		}
+		acc = makeCachedThunk(valuep)
+		if done.value {
+			break
+		}
+	}
+	return e.evaluate(acc)
+}
+
+func builtinSum(e *evaluator, arrp potentialValue) (value, error) {
+	arr, err := e.evaluateArray(arrp)
+	if err != nil {
+		return nil, err
+	}
+	sum := 0.0
+	for i, elem := range arr.elements {
+		v, err := e.evaluate(elem)
+		if err != nil {
+			return nil, err
+		}
+		num, ok := v.(*valueNumber)
+		if !ok {
+			return nil, e.Error(fmt.Sprintf("std.sum expects an array of numbers, got %s at index %d", v.typename(), i))
+		}
+		sum += num.value
+	}
+	return makeDoubleCheck(e, sum)
+}
+
+func builtinFilter(e *evaluator, funcp potentialValue, arrp potentialValue) (value, error) {
+	arr, err := e.evaluateArray(arrp)
+	if err != nil {
+		return nil, err
+	}
+	fun, err := e.evaluateFunction(funcp)
+	if err != nil {
+		return nil, err
+	}
+	num := int(arr.length())
+	// Start with capacity of the original array.
+	// This may spare us a few reallocations.
+	// TODO(sbarzowski) verify that it actually helps
+	elems := make([]potentialValue, 0, num)
+	for i := 0; i < num; i++ {
+		included, err := e.evaluateBoolean(fun.call(args(arr.elements[i])))
+		if err != nil {
+			return nil, err
+		}
+		if included.value {
+			elems = append(elems, arr.elements[i])
+		}
+	}
+	return makeValueArray(elems), nil
+}
+
+func builtinNegation(e *evaluator, xp potentialValue) (value, error) {
+	x, err := e.evaluateBoolean(xp)
+	if err != nil {
+		return nil, err
+	}
+	return makeValueBoolean(!x.value), nil
+}
+
+func builtinBitNeg(e *evaluator, xp potentialValue) (value, error) {
+	x, err := e.evaluateNumber(xp)
+	if err != nil {
+		return nil, err
+	}
+	i, err := bitwiseOperand(e, "~", x)
+	if err != nil {
+		return nil, err
+	}
+	return int64ToValue(^i), nil
+}
+
+func builtinIdentity(e *evaluator, xp potentialValue) (value, error) {
+	x, err := e.evaluate(xp)
+	if err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+func builtinUnaryMinus(e *evaluator, xp potentialValue) (value, error) {
+	x, err := e.evaluateNumber(xp)
+	if err != nil {
+		return nil, err
+	}
+	return makeValueNumber(-x.value), nil
+}
+
+func primitiveEquals(e *evaluator, xp potentialValue, yp potentialValue) (value, error) {
+	x, err := e.evaluate(xp)
+	if err != nil {
+		return nil, err
+	}
+	y, err := e.evaluate(yp)
+	if err != nil {
+		return nil, err
+	}
+	if x.typename() != y.typename() { // TODO(sbarzowski) ugh, string comparison
+		return makeValueBoolean(false), nil
+	}
+	switch left := x.(type) {
+	case *valueBoolean:
+		right, err := e.getBoolean(y)
+		if err != nil {
+			return nil, err
+		}
+		return makeValueBoolean(left.value == right.value), nil
+	case *valueNumber:
+		right, err := e.getNumber(y)
+		if err != nil {
+			return nil, err
+		}
+		// Plain float comparison is enough, because numbers are never NaN
+		// or infinite (see makeDoubleCheck). In particular -0 == 0.
+		return makeValueBoolean(left.value == right.value), nil
+	case *valueString:
+		right, err := e.getString(y)
+		if err != nil {
+			return nil, err
+		}
+		return makeValueBoolean(stringEqual(left, right)), nil
+	case *valueNull:
+		return makeValueBoolean(true), nil
+	case *valueFunction:
+		return nil, e.Error("Cannot test equality of functions")
+	default:
+		return nil, e.Error(
+			"primitiveEquals operates on primitive types, got " + x.typename(),
+		)
+	}
+}
+
+func builtinType(e *evaluator, xp potentialValue) (value, error) {
+	x, err := e.evaluate(xp)
+	if err != nil {
+		return nil, err
+	}
+	return makeValueString(x.typename()), nil
+}
+
+func builtinMd5(e *evaluator, xp potentialValue) (value, error) {
+	x, err := e.evaluateString(xp)
+	if err != nil {
+		return nil, err
+	}
+	hash := md5.Sum([]byte(string(x.value)))
+	return makeValueString(hex.EncodeToString(hash[:])), nil
+}
+
+// base64ChunkSize is the number of bytes passed to the encoder at once. It is
+// a multiple of 3, so that no padding is produced in the middle.
+const base64ChunkSize = 3 * 1024
+
+// builtinBase64 encodes a string or an array of bytes. The bytes are checked
+// and fed to the encoder as they are read, so a large array is never copied
+// into a byte slice and an invalid byte is reported with its index as soon as
+// it is reached.
+func builtinBase64(e *evaluator, inputp potentialValue) (value, error) {
+	input, err := e.evaluate(inputp)
+	if err != nil {
+		return nil, err
+	}
+	var length int
+	var byteAt func(index int) (int, error)
+	switch input := input.(type) {
+	case *valueString:
+		length = len(input.value)
+		byteAt = func(index int) (int, error) {
+			c := input.value[index]
+			if c > 255 {
+				return 0, e.Error(fmt.Sprintf("base64 expects a string or an array of bytes, got codepoint %d at index %d", c, index))
+			}
+			return int(c), nil
+		}
+	case *valueArray:
+		length = len(input.elements)
+		byteAt = func(index int) (int, error) {
+			elem, err := e.evaluate(input.elements[index])
+			if err != nil {
+				return 0, err
+			}
+			num, ok := elem.(*valueNumber)
+			if !ok {
+				return 0, e.Error(fmt.Sprintf("base64 expects a string or an array of bytes, got %s at index %d", elem.typename(), index))
+			}
+			if num.value != math.Floor(num.value) || num.value < 0 || num.value > 255 {
+				return 0, e.Error(fmt.Sprintf("base64 expects a string or an array of bytes, got %v at index %d", unparseNumber(num.value), index))
+			}
+			return int(num.value), nil
+		}
+	default:
+		return nil, e.Error(fmt.Sprintf("base64 expects a string or an array of bytes, got %s", input.typename()))
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(base64.StdEncoding.EncodedLen(length))
+	encoder := base64.NewEncoder(base64.StdEncoding, &buf)
+	chunk := make([]byte, 0, base64ChunkSize)
+	for index := 0; index < length; index++ {
+		b, err := byteAt(index)
+		if err != nil {
+			return nil, err
+		}
+		chunk = append(chunk, byte(b))
+		if len(chunk) == cap(chunk) {
+			encoder.Write(chunk)
+			chunk = chunk[:0]
+		}
+	}
+	encoder.Write(chunk)
+	encoder.Close()
+	return makeValueString(buf.String()), nil
+}
+
+// splitLimit implements std.split and std.splitLimit. Like upstream, the
+// separator may be longer than one character, but not empty. At most
+// maxSplits splits are done (all of them if it is -1), so the last element
+// holds the remainder.
+func splitLimit(e *evaluator, name string, strp, cp potentialValue, maxSplits int) (value, error) {
+	str, err := e.evaluate(strp)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := str.(*valueString); !ok {
+		return nil, e.Error(fmt.Sprintf("std.%s first parameter should be a string, got %s", name, str.typename()))
+	}
+	c, err := e.evaluate(cp)
+	if err != nil {
+		return nil, err
+	}
+	sep, ok := c.(*valueString)
+	if !ok {
+		return nil, e.Error(fmt.Sprintf("std.%s second parameter should be a string, got %s", name, c.typename()))
+	}
+	if sep.length() == 0 {
+		return nil, e.Error(fmt.Sprintf("std.%s second parameter should have length 1 or more", name))
+	}
+	n := -1
+	if maxSplits != -1 {
+		// Like upstream, other negative limits allow no splits at all.
+		n = 1
+		if maxSplits > 0 {
+			n = maxSplits + 1
+		}
+	}
+	parts := strings.SplitN(str.(*valueString).getString(), sep.getString(), n)
+	elems := make([]potentialValue, len(parts))
+	for i, part := range parts {
+		elems[i] = &readyValue{makeValueString(part)}
+	}
+	return makeValueArray(elems), nil
+}
+
+func builtinSplit(e *evaluator, strp, cp potentialValue) (value, error) {
+	return splitLimit(e, "split", strp, cp, -1)
+}
+
+func builtinSplitLimit(e *evaluator, strp, cp, maxSplitsp potentialValue) (value, error) {
+	maxSplits, err := e.evaluate(maxSplitsp)
+	if err != nil {
+		return nil, err
+	}
+	num, ok := maxSplits.(*valueNumber)
+	if !ok {
+		return nil, e.Error(fmt.Sprintf("std.splitLimit third parameter should be a number, got %s", maxSplits.typename()))
+	}
+	if num.value != math.Trunc(num.value) {
+		return nil, e.Error(fmt.Sprintf("std.splitLimit third parameter should be an integer, got %v", num.value))
+	}
+	// No string has more than MaxInt32 separators, so larger limits are the
+	// same as no limit.
+	limit := -1
+	if num.value <= math.MaxInt32 {
+		limit = int(math.Max(num.value, math.MinInt32))
+	}
+	return splitLimit(e, "splitLimit", strp, cp, limit)
+}
+
+// splitOnSpace splits s on runs of runes for which isSpace holds, dropping
+// empty tokens, so leading and trailing whitespace produce no extra words.
+func splitOnSpace(s []rune, isSpace func(rune) bool) *valueArray {
+	elems := []potentialValue{}
+	start := -1
+	for i, r := range s {
+		if isSpace(r) {
+			if start >= 0 {
+				elems = append(elems, &readyValue{&valueString{value: s[start:i]}})
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		elems = append(elems, &readyValue{&valueString{value: s[start:]}})
+	}
+	return makeValueArray(elems)
+}
+
+func isASCIISpace(r rune) bool {
+	switch r {
+	case ' ', '\t', '\n', '\v', '\f', '\r':
+		return true
+	}
+	return false
+}
+
+func builtinSplitWhitespace(e *evaluator, strp potentialValue) (value, error) {
+	str, err := e.evaluateString(strp)
+	if err != nil {
+		return nil, err
+	}
+	return splitOnSpace(str.value, isASCIISpace), nil
+}
+
+// builtinWords is like splitWhitespace, but also splits on non-ASCII
+// whitespace such as U+00A0 (no-break space) and U+3000 (ideographic space).
+func builtinWords(e *evaluator, strp potentialValue) (value, error) {
+	str, err := e.evaluateString(strp)
+	if err != nil {
+		return nil, err
+	}
+	return splitOnSpace(str.value, unicode.IsSpace), nil
+}
+
+// stripChars removes the codepoints present in chars from the beginning
+// and/or the end of str.
+func stripChars(e *evaluator, strp, charsp potentialValue, left, right bool) (value, error) {
+	str, err := e.evaluateString(strp)
+	if err != nil {
+		return nil, err
+	}
+	chars, err := e.evaluateString(charsp)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[rune]bool, len(chars.value))
+	for _, c := range chars.value {
+		set[c] = true
+	}
+	from, to := 0, len(str.value)
+	if left {
+		for from < to && set[str.value[from]] {
+			from++
+		}
+	}
+	if right {
+		for to > from && set[str.value[to-1]] {
+			to--
+		}
+	}
+	return &valueString{value: str.value[from:to]}, nil
+}
+
+func builtinStripChars(e *evaluator, strp, charsp potentialValue) (value, error) {
+	return stripChars(e, strp, charsp, true, true)
+}
+
+func builtinLstripChars(e *evaluator, strp, charsp potentialValue) (value, error) {
+	return stripChars(e, strp, charsp, true, false)
+}
+
+func builtinRstripChars(e *evaluator, strp, charsp potentialValue) (value, error) {
+	return stripChars(e, strp, charsp, false, true)
+}
+
+func asciiUpper(r rune) rune {
+	if r >= 'a' && r <= 'z' {
+		return r - 'a' + 'A'
+	}
+	return r
+}
+
+func asciiLower(r rune) rune {
+	if r >= 'A' && r <= 'Z' {
+		return r - 'A' + 'a'
+	}
+	return r
+}
+
+// capitalizeWords uppercases the first character of each word and lowercases
+// the other ones. Only ASCII letters are changed, so a word starting with a
+// digit or a non-ASCII letter keeps its first character. Words are str
+// without leading whitespace or, if eachWord is set, separated by whitespace.
+func capitalizeWords(str []rune, eachWord bool) *valueString {
+	result := make([]rune, len(str))
+	wordStart := true
+	for i, r := range str {
+		switch {
+		case isASCIISpace(r):
+			result[i] = r
+			wordStart = wordStart || eachWord
+		case wordStart:
+			result[i] = asciiUpper(r)
+			wordStart = false
+		default:
+			result[i] = asciiLower(r)
+		}
+	}
+	return &valueString{value: result}
+}
+
+func builtinCapitalize(e *evaluator, strp potentialValue) (value, error) {
+	str, err := e.evaluateString(strp)
+	if err != nil {
+		return nil, err
+	}
+	return capitalizeWords(str.value, false), nil
+}
+
+func builtinTitle(e *evaluator, strp potentialValue) (value, error) {
+	str, err := e.evaluateString(strp)
+	if err != nil {
+		return nil, err
+	}
+	return capitalizeWords(str.value, true), nil
+}
+
+// builtinStripMargin removes leading blanks followed by the margin character
+// from every line of str. Lines without the margin are left unchanged.
+func builtinStripMargin(e *evaluator, args []potentialValue) (value, error) {
+	str, err := e.evaluateString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	marginChar, err := e.evaluateString(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if marginChar.length() != 1 {
+		return nil, e.Error(fmt.Sprintf("stripMargin expects a single character margin, got %v", unparseString(marginChar.getString())))
+	}
+	margin := marginChar.value[0]
+	var result []rune
+	lineStart := true
+	for i := 0; i < len(str.value); i++ {
+		if lineStart {
+			lineStart = false
+			j := i
+			for j < len(str.value) && (str.value[j] == ' ' || str.value[j] == '\t') {
+				j++
+			}
+			if j < len(str.value) && str.value[j] == margin {
+				i = j
+				continue
+			}
+		}
+		result = append(result, str.value[i])
+		if str.value[i] == '\n' {
+			lineStart = true
+		}
+	}
+	return &valueString{value: result}, nil
+}
+
+// Maximum allowed unicode codepoint
+// https://en.wikipedia.org/wiki/Unicode#Architecture_and_terminology
+const codepointMax = 0x10FFFF
+
+func builtinChar(e *evaluator, xp potentialValue) (value, error) {
+	x, err := e.evaluateNumber(xp)
+	if err != nil {
+		return nil, err
+	}
+	if x.value > codepointMax {
+		return nil, e.Error(fmt.Sprintf("Invalid unicode codepoint, got %v", x.value))
+	} else if x.value < 0 {
+		return nil, e.Error(fmt.Sprintf("Codepoints must be >= 0, got %v", x.value))
+	}
+	return makeValueString(string(rune(x.value))), nil
+}
+
+func builtinCodepoint(e *evaluator, xp potentialValue) (value, error) {
+	x, err := e.evaluateString(xp)
+	if err != nil {
+		return nil, err
+	}
+	if x.length() != 1 {
+		return nil, e.Error(fmt.Sprintf("codepoint takes a string of length 1, got length %v", x.length()))
+	}
+	return makeValueNumber(float64(x.value[0])), nil
+}
+
+func makeDoubleCheck(e *evaluator, x float64) (value, error) {
+	if math.IsNaN(x) {
+		return nil, e.Error("Not a number")
+	}
+	if math.IsInf(x, 0) {
+		return nil, e.Error("Overflow")
+	}
+	return makeValueNumber(x), nil
+}
+
+func liftNumeric(f func(float64) float64) func(*evaluator, potentialValue) (value, error) {
+	return func(e *evaluator, xp potentialValue) (value, error) {
+		x, err := e.evaluateNumber(xp)
+		if err != nil {
+			return nil, err
+		}
+		return makeDoubleCheck(e, f(x.value))
+	}
+}
+
+var builtinSqrt = liftNumeric(math.Sqrt)
+var builtinCeil = liftNumeric(math.Ceil)
+var builtinFloor = liftNumeric(math.Floor)
+var builtinSin = liftNumeric(math.Sin)
+var builtinCos = liftNumeric(math.Cos)
 var builtinTan = liftNumeric(math.Tan)
 var builtinAsin = liftNumeric(math.Asin)
 var builtinAcos = liftNumeric(math.Acos)
@@ -479,30 +1647,235 @@ var builtinExponent = liftNumeric(func(f float64) float64 {
 	return float64(exponent)
 })
 
-func liftBitwise(f func(int64, int64) int64) func(*evaluator, potentialValue, potentialValue) (value, error) {
-	return func(e *evaluator, xp, yp potentialValue) (value, error) {
-		x, err := e.evaluateNumber(xp)
+// maxSafeInteger is the largest integer n such that n and n + 1 are both
+// exactly representable as a float64.
+const maxSafeInteger = 1<<53 - 1
+
+// isSafeInteger reports whether f is an integer which a float64 represents
+// exactly, with no other integer rounding to it.
+func isSafeInteger(f float64) bool {
+	return f == math.Trunc(f) && math.Abs(f) <= maxSafeInteger
+}
+
+// bitwiseOperand converts x to an integer for the bitwise operator op. With
+// the strictBitwise option x must be a safe integer, otherwise it is truncated
+// towards zero.
+func bitwiseOperand(e *evaluator, op string, x *valueNumber) (int64, error) {
+	if e.i.evalOpts.strictBitwise && !isSafeInteger(x.value) {
+		return 0, e.Error(fmt.Sprintf("Bitwise operator %s expects an integer in [-(2^53 - 1), 2^53 - 1], got %v", op, unparseNumber(x.value)))
+	}
+	return int64(x.value), nil
+}
+
+// liftBitwise makes a builtin for the bitwise operator op. If shift is true,
+// y is a shift amount, which the strictBitwise option limits to [0, 63].
+func liftBitwise(op string, shift bool, f func(int64, int64) int64) func(*evaluator, potentialValue, potentialValue) (value, error) {
+	return func(e *evaluator, xp, yp potentialValue) (value, error) {
+		x, err := e.evaluateNumber(xp)
+		if err != nil {
+			return nil, err
+		}
+		y, err := e.evaluateNumber(yp)
+		if err != nil {
+			return nil, err
+		}
+		xInt, err := bitwiseOperand(e, op, x)
+		if err != nil {
+			return nil, err
+		}
+		yInt, err := bitwiseOperand(e, op, y)
+		if err != nil {
+			return nil, err
+		}
+		if shift && e.i.evalOpts.strictBitwise && (yInt < 0 || yInt > 63) {
+			return nil, e.Error(fmt.Sprintf("Shift amount for operator %s should be in [0, 63], got %v", op, yInt))
+		}
+		return makeDoubleCheck(e, float64(f(xInt, yInt)))
+	}
+}
+
+// TODO(sbarzowski) negative shifts
+var builtinShiftL = liftBitwise("<<", true, func(x, y int64) int64 { return x << uint(y) })
+var builtinShiftR = liftBitwise(">>", true, func(x, y int64) int64 { return x >> uint(y) })
+var builtinBitwiseAnd = liftBitwise("&", false, func(x, y int64) int64 { return x & y })
+var builtinBitwiseOr = liftBitwise("|", false, func(x, y int64) int64 { return x | y })
+var builtinBitwiseXor = liftBitwise("^", false, func(x, y int64) int64 { return x ^ y })
+
+func builtinObjectFieldsEx(e *evaluator, objp potentialValue, includeHiddenP potentialValue) (value, error) {
+	obj, err := e.evaluateObject(objp)
+	if err != nil {
+		return nil, err
+	}
+	includeHidden, err := e.evaluateBoolean(includeHiddenP)
+	if err != nil {
+		return nil, err
+	}
+	fields := objectFieldsSorted(obj, withHiddenFromBool(includeHidden.value))
+	elems := []potentialValue{}
+	for _, fieldname := range fields {
+		elems = append(elems, &readyValue{makeValueString(fieldname)})
+	}
+	return makeValueArray(elems), nil
+}
+
+// builtinObjectFieldsMatching returns the visible fields of obj, sorted, for
+// which pred returns true.
+func builtinObjectFieldsMatching(e *evaluator, objp potentialValue, predp potentialValue) (value, error) {
+	obj, err := e.evaluateObject(objp)
+	if err != nil {
+		return nil, err
+	}
+	pred, err := e.evaluateFunction(predp)
+	if err != nil {
+		return nil, err
+	}
+	elems := []potentialValue{}
+	for _, fieldname := range objectFieldsSorted(obj, withoutHidden) {
+		name := &readyValue{makeValueString(fieldname)}
+		included, err := e.evaluateBoolean(pred.call(args(name)))
+		if err != nil {
+			return nil, err
+		}
+		if included.value {
+			elems = append(elems, name)
+		}
+	}
+	return makeValueArray(elems), nil
+}
+
+// objectFieldsModes maps the modes accepted by std.objectFieldsMode to the
+// fields they select.
+var objectFieldsModes = map[string]Hidden{
+	"all":     withHidden,
+	"visible": withoutHidden,
+	"hidden":  onlyHidden,
+}
+
+// builtinObjectFieldsMode is like builtinObjectFieldsEx, but can also list
+// only the hidden fields. A field is hidden if its effective visibility is ::,
+// e.g. f: in { f:: 1 } + { f: 2 } is hidden.
+func builtinObjectFieldsMode(e *evaluator, objp potentialValue, modep potentialValue) (value, error) {
+	obj, err := e.evaluateObject(objp)
+	if err != nil {
+		return nil, err
+	}
+	mode, err := e.evaluateString(modep)
+	if err != nil {
+		return nil, err
+	}
+	hidden, ok := objectFieldsModes[mode.getString()]
+	if !ok {
+		return nil, e.Error(fmt.Sprintf("objectFieldsMode expects mode to be \"all\", \"visible\" or \"hidden\", got %v", unparseString(mode.getString())))
+	}
+	fields := objectFieldsSorted(obj, hidden)
+	elems := make([]potentialValue, 0, len(fields))
+	for _, fieldname := range fields {
+		elems = append(elems, &readyValue{makeValueString(fieldname)})
+	}
+	return makeValueArray(elems), nil
+}
+
+// objectKeysValues returns the fields of obj as { key, value } objects, in
+// the order given by preserveOrder. The values are not evaluated.
+func objectKeysValues(e *evaluator, objp potentialValue, hidden Hidden, preserveOrderp potentialValue) (value, error) {
+	obj, err := e.evaluateObject(objp)
+	if err != nil {
+		return nil, err
+	}
+	preserveOrder, err := e.evaluateBoolean(preserveOrderp)
+	if err != nil {
+		return nil, err
+	}
+	err = checkAssertions(e, obj)
+	if err != nil {
+		return nil, err
+	}
+	fields := objectFieldsOrdered(obj, hidden, preserveOrder.value)
+	elems := make([]potentialValue, 0, len(fields))
+	for _, fieldname := range fields {
+		fieldp := tryObjectIndex(objectBinding(obj), fieldname, hidden)
+		elems = append(elems, &readyValue{makeValueSimpleObject(nil, valueSimpleObjectFieldMap{
+			"key":   {hide: ast.ObjectFieldInherit, field: &readyValue{makeValueString(fieldname)}, order: 0},
+			"value": {hide: ast.ObjectFieldInherit, field: &potentialValueUnboundField{fieldp}, order: 1},
+		}, nil)})
+	}
+	return makeValueArray(elems), nil
+}
+
+func builtinObjectKeysValues(e *evaluator, args []potentialValue) (value, error) {
+	return objectKeysValues(e, args[0], withoutHidden, args[1])
+}
+
+func builtinObjectKeysValuesAll(e *evaluator, args []potentialValue) (value, error) {
+	return objectKeysValues(e, args[0], withHidden, args[1])
+}
+
+// builtinObjectValuesEx returns the values of the fields, sorted by field name.
+// The elements are not evaluated, so an error in one field only surfaces when
+// that element is used.
+func builtinObjectValuesEx(e *evaluator, objp potentialValue, includeHiddenP potentialValue) (value, error) {
+	obj, err := e.evaluateObject(objp)
+	if err != nil {
+		return nil, err
+	}
+	includeHidden, err := e.evaluateBoolean(includeHiddenP)
+	if err != nil {
+		return nil, err
+	}
+	err = checkAssertions(e, obj)
+	if err != nil {
+		return nil, err
+	}
+	hidden := withHiddenFromBool(includeHidden.value)
+	fields := objectFieldsSorted(obj, hidden)
+	elems := make([]potentialValue, 0, len(fields))
+	for _, fieldname := range fields {
+		fieldp := tryObjectIndex(objectBinding(obj), fieldname, hidden)
+		elems = append(elems, makeCachedThunk(fieldp))
+	}
+	return makeValueArray(elems), nil
+}
+
+// builtinPluck returns the field key of each object in arr. Objects without
+// the field are skipped, but elements which are not objects are an error.
+// Like indexing, it includes hidden fields. The fields are not evaluated.
+func builtinPluck(e *evaluator, keyp potentialValue, arrp potentialValue) (value, error) {
+	key, err := e.evaluateString(keyp)
+	if err != nil {
+		return nil, err
+	}
+	arr, err := e.evaluateArray(arrp)
+	if err != nil {
+		return nil, err
+	}
+	var elems []potentialValue
+	for i, elem := range arr.elements {
+		elemValue, err := e.evaluate(elem)
 		if err != nil {
 			return nil, err
 		}
-		y, err := e.evaluateNumber(yp)
+		obj, ok := elemValue.(valueObject)
+		if !ok {
+			return nil, e.Error(fmt.Sprintf("pluck expects an array of objects, got %v at index %d", elemValue.typename(), i))
+		}
+		err = checkAssertions(e, obj)
 		if err != nil {
 			return nil, err
 		}
-		xInt := int64(x.value)
-		yInt := int64(y.value)
-		return makeDoubleCheck(e, float64(f(xInt, yInt)))
+		if fieldp := tryObjectIndex(objectBinding(obj), key.getString(), withHidden); fieldp != nil {
+			elems = append(elems, makeCachedThunk(fieldp))
+		}
 	}
+	return makeValueArray(elems), nil
 }
 
-// TODO(sbarzowski) negative shifts
-var builtinShiftL = liftBitwise(func(x, y int64) int64 { return x << uint(y) })
-var builtinShiftR = liftBitwise(func(x, y int64) int64 { return x >> uint(y) })
-var builtinBitwiseAnd = liftBitwise(func(x, y int64) int64 { return x & y })
-var builtinBitwiseOr = liftBitwise(func(x, y int64) int64 { return x | y })
-var builtinBitwiseXor = liftBitwise(func(x, y int64) int64 { return x ^ y })
-
-func builtinObjectFieldsEx(e *evaluator, objp potentialValue, includeHiddenP potentialValue) (value, error) {
+// builtinMapWithKeyEx returns an object with the same fields as obj, each
+// field mapped with func(name, value). The fields keep their hide levels.
+func builtinMapWithKeyEx(e *evaluator, funcp potentialValue, objp potentialValue, includeHiddenP potentialValue) (value, error) {
+	fun, err := e.evaluateFunction(funcp)
+	if err != nil {
+		return nil, err
+	}
 	obj, err := e.evaluateObject(objp)
 	if err != nil {
 		return nil, err
@@ -511,13 +1884,183 @@ func builtinObjectFieldsEx(e *evaluator, objp potentialValue, includeHiddenP pot
 	if err != nil {
 		return nil, err
 	}
-	fields := objectFields(obj, withHiddenFromBool(includeHidden.value))
-	sort.Strings(fields)
-	elems := []potentialValue{}
-	for _, fieldname := range fields {
-		elems = append(elems, &readyValue{makeValueString(fieldname)})
+	err = checkAssertions(e, obj)
+	if err != nil {
+		return nil, err
 	}
-	return makeValueArray(elems), nil
+	hidden := withHiddenFromBool(includeHidden.value)
+	visibility := objectFieldsVisibility(obj)
+	fields := make(valueSimpleObjectFieldMap)
+	for _, fieldName := range objectFieldsInDefinitionOrder(obj, hidden) {
+		fieldp := tryObjectIndex(objectBinding(obj), fieldName, hidden)
+		mapped := fun.call(args(&readyValue{makeValueString(fieldName)}, fieldp))
+		fields[fieldName] = valueSimpleObjectField{
+			hide:  visibility[fieldName],
+			field: &potentialValueUnboundField{mapped},
+			order: len(fields),
+		}
+	}
+	return makeValueSimpleObject(nil, fields, nil), nil
+}
+
+// builtinFilterObject returns an object with the visible fields of obj whose
+// values satisfy pred. Only the values are evaluated, to test them.
+func builtinFilterObject(e *evaluator, predp potentialValue, objp potentialValue) (value, error) {
+	pred, err := e.evaluateFunction(predp)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := e.evaluateObject(objp)
+	if err != nil {
+		return nil, err
+	}
+	err = checkAssertions(e, obj)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(valueSimpleObjectFieldMap)
+	for _, fieldName := range objectFieldsInDefinitionOrder(obj, withoutHidden) {
+		fieldp := makeCachedThunk(tryObjectIndex(objectBinding(obj), fieldName, withoutHidden))
+		included, err := e.evaluateBoolean(pred.call(args(fieldp)))
+		if err != nil {
+			return nil, err
+		}
+		if included.value {
+			fields[fieldName] = valueSimpleObjectField{
+				hide:  ast.ObjectFieldInherit,
+				field: &potentialValueUnboundField{fieldp},
+				order: len(fields),
+			}
+		}
+	}
+	return makeValueSimpleObject(nil, fields, nil), nil
+}
+
+// selectFields returns an object with the fields of obj (hidden or not) which
+// are listed in keys if pick is true, or which are not listed otherwise. The
+// fields keep their hide levels and are not evaluated.
+func selectFields(e *evaluator, builtin string, objp potentialValue, keysp potentialValue, pick bool) (value, error) {
+	obj, err := e.evaluateObject(objp)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := e.evaluateArray(keysp)
+	if err != nil {
+		return nil, err
+	}
+	listed := make(map[string]bool, len(keys.elements))
+	for i, keyp := range keys.elements {
+		keyValue, err := e.evaluate(keyp)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := keyValue.(*valueString)
+		if !ok {
+			return nil, e.Error(fmt.Sprintf("std.%s expects an array of strings, got %v at index %d", builtin, keyValue.typename(), i))
+		}
+		listed[key.getString()] = true
+	}
+	err = checkAssertions(e, obj)
+	if err != nil {
+		return nil, err
+	}
+	visibility := objectFieldsVisibility(obj)
+	fields := make(valueSimpleObjectFieldMap)
+	for _, fieldName := range objectFieldsInDefinitionOrder(obj, withHidden) {
+		if listed[fieldName] != pick {
+			continue
+		}
+		fields[fieldName] = valueSimpleObjectField{
+			hide:  visibility[fieldName],
+			field: &potentialValueUnboundField{tryObjectIndex(objectBinding(obj), fieldName, withHidden)},
+			order: len(fields),
+		}
+	}
+	return makeValueSimpleObject(nil, fields, nil), nil
+}
+
+// builtinPick returns an object with only the fields of obj listed in keys.
+// Keys which obj doesn't have are skipped.
+func builtinPick(e *evaluator, objp potentialValue, keysp potentialValue) (value, error) {
+	return selectFields(e, "pick", objp, keysp, true)
+}
+
+// builtinOmit returns an object with the fields of obj not listed in keys.
+func builtinOmit(e *evaluator, objp potentialValue, keysp potentialValue) (value, error) {
+	return selectFields(e, "omit", objp, keysp, false)
+}
+
+// builtinWithDefaults implements std.withDefaults(obj, defaults): obj extended
+// with the fields of defaults which obj doesn't have, keeping their hide
+// levels. The fields of obj keep their hide levels and are not merged with the
+// defaults. The added fields stay bound to defaults, so neither its self nor
+// its asserts affect the result. Nothing is evaluated, so defaults which are
+// overridden may even fail.
+func builtinWithDefaults(e *evaluator, objp potentialValue, defaultsp potentialValue) (value, error) {
+	objValue, err := e.evaluate(objp)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := objValue.(valueObject)
+	if !ok {
+		return nil, e.Error(fmt.Sprintf("std.withDefaults first parameter should be an object, got %s", objValue.typename()))
+	}
+	defaultsValue, err := e.evaluate(defaultsp)
+	if err != nil {
+		return nil, err
+	}
+	defaults, ok := defaultsValue.(valueObject)
+	if !ok {
+		return nil, e.Error(fmt.Sprintf("std.withDefaults second parameter should be an object, got %s", defaultsValue.typename()))
+	}
+	visibility := objectFieldsVisibility(defaults)
+	fields := make(valueSimpleObjectFieldMap)
+	for _, fieldName := range objectFieldsInDefinitionOrder(defaults, withHidden) {
+		if objectHasField(objectBinding(obj), fieldName, withHidden) {
+			continue
+		}
+		fields[fieldName] = valueSimpleObjectField{
+			hide:  visibility[fieldName],
+			field: &potentialValueUnboundField{tryObjectIndex(objectBinding(defaults), fieldName, withHidden)},
+			order: len(fields),
+		}
+	}
+	return makeValueExtendedObject(obj, makeValueSimpleObject(nil, fields, nil)), nil
+}
+
+// builtinInvertObject returns an object mapping the values of the visible
+// fields of obj, which must be strings, back to their names. When several
+// fields have the same value, the last one in sorted order wins.
+func builtinInvertObject(e *evaluator, objp potentialValue) (value, error) {
+	obj, err := e.evaluateObject(objp)
+	if err != nil {
+		return nil, err
+	}
+	err = checkAssertions(e, obj)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(valueSimpleObjectFieldMap)
+	for _, fieldName := range objectFieldsSorted(obj, withoutHidden) {
+		fieldValue, err := e.evaluate(tryObjectIndex(objectBinding(obj), fieldName, withoutHidden))
+		if err != nil {
+			return nil, err
+		}
+		str, ok := fieldValue.(*valueString)
+		if !ok {
+			return nil, e.Error(fmt.Sprintf("std.invertObject expects string values, got %v in field %v", fieldValue.typename(), unparseString(fieldName)))
+		}
+		field := valueSimpleObjectField{
+			hide:  ast.ObjectFieldInherit,
+			field: &readyValue{makeValueString(fieldName)},
+			order: len(fields),
+		}
+		if previous, ok := fields[str.getString()]; ok {
+			field.order = previous.order
+		}
+		fields[str.getString()] = field
+	}
+	return makeValueSimpleObject(nil, fields, nil), nil
 }
 
 func builtinObjectHasEx(e *evaluator, objp potentialValue, fnamep potentialValue, includeHiddenP potentialValue) (value, error) {
@@ -534,8 +2077,144 @@ func builtinObjectHasEx(e *evaluator, objp potentialValue, fnamep potentialValue
 		return nil, err
 	}
 	h := withHiddenFromBool(includeHidden.value)
-	fieldp := tryObjectIndex(objectBinding(obj), string(fname.value), h)
-	return makeValueBoolean(fieldp != nil), nil
+	return makeValueBoolean(objectHasField(objectBinding(obj), string(fname.value), h)), nil
+}
+
+// walkObjectPath follows the path of length fields from obj, where
+// fieldName(index) is the name of a field. It returns the object holding the
+// last field and its name, or a nil object if a field on the way is missing
+// or not an object. Like indexing, it includes hidden fields. The path must
+// not be empty.
+func walkObjectPath(e *evaluator, obj valueObject, length int, fieldName func(index int) (string, error)) (valueObject, string, error) {
+	for index := 0; ; index++ {
+		name, err := fieldName(index)
+		if err != nil {
+			return nil, "", err
+		}
+		err = checkAssertions(e, obj)
+		if err != nil {
+			return nil, "", err
+		}
+		if index == length-1 {
+			return obj, name, nil
+		}
+		fieldp := tryObjectIndex(objectBinding(obj), name, withHidden)
+		if fieldp == nil {
+			return nil, "", nil
+		}
+		field, err := e.evaluate(fieldp)
+		if err != nil {
+			return nil, "", err
+		}
+		next, ok := field.(valueObject)
+		if !ok {
+			return nil, "", nil
+		}
+		obj = next
+	}
+}
+
+// builtinHasPath tells whether obj.path[0].path[1]... exists, where the
+// intermediate fields are objects. Like indexing, it includes hidden fields.
+// Only the intermediate fields are evaluated, not the last one.
+func builtinHasPath(e *evaluator, objp potentialValue, pathp potentialValue) (value, error) {
+	obj, err := e.evaluateObject(objp)
+	if err != nil {
+		return nil, err
+	}
+	path, err := e.evaluateArray(pathp)
+	if err != nil {
+		return nil, err
+	}
+	if len(path.elements) == 0 {
+		return makeValueBoolean(true), nil
+	}
+	parent, last, err := walkObjectPath(e, obj, len(path.elements), func(index int) (string, error) {
+		fieldName, err := e.evaluateString(path.elements[index])
+		if err != nil {
+			return "", err
+		}
+		return fieldName.getString(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil {
+		return makeValueBoolean(false), nil
+	}
+	return makeValueBoolean(objectHasField(objectBinding(parent), last, withHidden)), nil
+}
+
+// builtinTreeGet returns obj.a.b.c for the path "a.b.c", split on sep. If a
+// field on the way is missing or not an object, it returns default instead.
+// Like indexing, it includes hidden fields.
+func builtinTreeGet(e *evaluator, args []potentialValue) (value, error) {
+	obj, err := e.evaluateObject(args[0])
+	if err != nil {
+		return nil, err
+	}
+	path, err := e.evaluateString(args[1])
+	if err != nil {
+		return nil, err
+	}
+	sep, err := e.evaluateString(args[2])
+	if err != nil {
+		return nil, err
+	}
+	if sep.length() == 0 {
+		return nil, e.Error("std.treeGet separator should not be empty")
+	}
+	fieldNames := strings.Split(path.getString(), sep.getString())
+	parent, last, err := walkObjectPath(e, obj, len(fieldNames), func(index int) (string, error) {
+		return fieldNames[index], nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil {
+		return e.evaluate(args[3])
+	}
+	fieldp := tryObjectIndex(objectBinding(parent), last, withHidden)
+	if fieldp == nil {
+		return e.evaluate(args[3])
+	}
+	return e.evaluate(fieldp)
+}
+
+func builtinFieldSource(e *evaluator, objp potentialValue, fnamep potentialValue) (value, error) {
+	obj, err := e.evaluateObject(objp)
+	if err != nil {
+		return nil, err
+	}
+	fname, err := e.evaluateString(fnamep)
+	if err != nil {
+		return nil, err
+	}
+	found, own := objectFieldOrigin(obj, string(fname.value))
+	switch {
+	case !found:
+		return makeValueNull(), nil
+	case own:
+		return makeValueString("own"), nil
+	default:
+		return makeValueString("super"), nil
+	}
+}
+
+// builtinFieldIsComputed reports whether the name of the (possibly inherited)
+// field was computed, as in ["b"]: 2, rather than written as an identifier.
+// Missing fields and fields of objects created by builtins are not computed.
+func builtinFieldIsComputed(e *evaluator, objp potentialValue, fnamep potentialValue) (value, error) {
+	obj, err := e.evaluateObject(objp)
+	if err != nil {
+		return nil, err
+	}
+	fname, err := e.evaluateString(fnamep)
+	if err != nil {
+		return nil, err
+	}
+	field, _, _ := findField(obj, 0, string(fname.value))
+	return makeValueBoolean(field != nil && field.computed), nil
 }
 
 func builtinPow(e *evaluator, basep potentialValue, expp potentialValue) (value, error) {
@@ -550,7 +2229,18 @@ func builtinPow(e *evaluator, basep potentialValue, expp potentialValue) (value,
 	return makeDoubleCheck(e, math.Pow(base.value, exp.value))
 }
 
-func builtinUglyObjectFlatMerge(e *evaluator, objarrp potentialValue) (value, error) {
+// duplicateComprehensionFieldErrMsg describes a field produced more than once by
+// an object comprehension. We follow upstream and treat it as an error, rather than
+// picking one of the values.
+func duplicateComprehensionFieldErrMsg(fieldName string, compLoc *ast.LocationRange) string {
+	if compLoc == nil || !compLoc.IsSet() {
+		return duplicateFieldNameErrMsg(fieldName)
+	}
+	return fmt.Sprintf("%s, produced more than once by object comprehension at %v",
+		duplicateFieldNameErrMsg(fieldName), compLoc)
+}
+
+func builtinUglyObjectFlatMerge(e *evaluator, objarrp potentialValue, compLoc *ast.LocationRange) (value, error) {
 	objarr, err := e.evaluateArray(objarrp)
 	if err != nil {
 		return nil, err
@@ -568,7 +2258,7 @@ func builtinUglyObjectFlatMerge(e *evaluator, objarrp potentialValue) (value, er
 		simpleObj := obj.(*valueSimpleObject)
 		for fieldName, fieldVal := range simpleObj.fields {
 			if _, alreadyExists := newFields[fieldName]; alreadyExists {
-				return nil, e.Error(duplicateFieldNameErrMsg(fieldName))
+				return nil, e.Error(duplicateComprehensionFieldErrMsg(fieldName, compLoc))
 			}
 			newFields[fieldName] = valueSimpleObjectField{
 				hide: fieldVal.hide,
@@ -576,13 +2266,15 @@ func builtinUglyObjectFlatMerge(e *evaluator, objarrp potentialValue) (value, er
 					inner:    fieldVal.field,
 					bindings: simpleObj.upValues,
 				},
+				order:    len(newFields),
+				computed: fieldVal.computed,
 			}
 		}
 	}
 	return makeValueSimpleObject(
 		nil, // no binding frame
 		newFields,
-		[]unboundField{}, // No asserts allowed
+		[]objectAssert{}, // No asserts allowed
 	), nil
 }
 
@@ -606,6 +2298,10 @@ type UnaryBuiltin struct {
 	name       ast.Identifier
 	function   unaryBuiltin
 	parameters ast.Identifiers
+	// The result depends only on the argument and there are no side effects,
+	// so results for string arguments can be cached. Only worth it for
+	// builtins which cost much more than the lookup.
+	pure bool
 }
 
 func getBuiltinEvaluator(e *evaluator, name ast.Identifier) *evaluator {
@@ -618,9 +2314,48 @@ func getBuiltinEvaluator(e *evaluator, name ast.Identifier) *evaluator {
 func (b *UnaryBuiltin) EvalCall(args callArguments, e *evaluator) (value, error) {
 
 	// TODO check args
+	if b.pure {
+		return b.evalCached(getBuiltinEvaluator(e, b.name), args.positional[0])
+	}
 	return b.function(getBuiltinEvaluator(e, b.name), args.positional[0])
 }
 
+// maxBuiltinCacheSize limits the number of results remembered by the
+// interpreter, so that calls on many different arguments don't keep them
+// all alive.
+const maxBuiltinCacheSize = 1024
+
+// builtinCacheKey identifies a call of a pure builtin on a string.
+type builtinCacheKey struct {
+	builtin ast.Identifier
+	arg     string
+}
+
+// evalCached calls a pure builtin, reusing the result of an earlier call on
+// an equal string in the same evaluation.
+func (b *UnaryBuiltin) evalCached(e *evaluator, xp potentialValue) (value, error) {
+	x, err := e.evaluate(xp)
+	if err != nil {
+		return nil, err
+	}
+	str, ok := x.(*valueString)
+	if !ok {
+		return b.function(e, &readyValue{x})
+	}
+	key := builtinCacheKey{builtin: b.name, arg: str.getString()}
+	if result, ok := e.i.builtinCache[key]; ok {
+		return result, nil
+	}
+	result, err := b.function(e, &readyValue{x})
+	if err != nil {
+		return nil, err
+	}
+	if len(e.i.builtinCache) < maxBuiltinCacheSize {
+		e.i.builtinCache[key] = result
+	}
+	return result, nil
+}
+
 func (b *UnaryBuiltin) Parameters() ast.Identifiers {
 	return b.parameters
 }
@@ -655,6 +2390,149 @@ func (b *TernaryBuiltin) Parameters() ast.Identifiers {
 	return b.parameters
 }
 
+// objectFlatMergeBuiltin is the builtin used by desugared object comprehensions.
+// Unlike other builtins it needs the location it was called from, which is
+// the location of the comprehension itself.
+type objectFlatMergeBuiltin struct {
+	name ast.Identifier
+}
+
+func (b *objectFlatMergeBuiltin) EvalCall(args callArguments, e *evaluator) (value, error) {
+	return builtinUglyObjectFlatMerge(getBuiltinEvaluator(e, b.name), args.positional[0], e.trace.loc)
+}
+
+func (b *objectFlatMergeBuiltin) Parameters() ast.Identifiers {
+	return ast.Identifiers{"x"}
+}
+
+// traceBuiltin is std.trace. Like objectFlatMergeBuiltin, it needs the
+// location it was called from.
+type traceBuiltin struct{}
+
+func (b *traceBuiltin) EvalCall(args callArguments, e *evaluator) (value, error) {
+	return builtinTrace(getBuiltinEvaluator(e, "trace"), args.positional[0], args.positional[1], e.trace.loc)
+}
+
+func (b *traceBuiltin) Parameters() ast.Identifiers {
+	return ast.Identifiers{"str", "rest"}
+}
+
+// foldBuiltin is std.foldl or std.foldr. Like objectFlatMergeBuiltin, it
+// needs the location it was called from, to tell whether the standard
+// library called it.
+type foldBuiltin struct {
+	name     ast.Identifier
+	function func(e *evaluator, funcp, arrp, initp potentialValue, reportIndex bool) (value, error)
+}
+
+func (b *foldBuiltin) EvalCall(args callArguments, e *evaluator) (value, error) {
+	fromStd := stdLocations[e.trace.loc]
+	return b.function(getBuiltinEvaluator(e, b.name), args.positional[0], args.positional[1], args.positional[2], !fromStd)
+}
+
+func (b *foldBuiltin) Parameters() ast.Identifiers {
+	return ast.Identifiers{"func", "arr", "init"}
+}
+
+// nativeCallable calls a NativeFunction registered in the VM.
+type nativeCallable struct {
+	f *NativeFunction
+}
+
+func (c *nativeCallable) EvalCall(args callArguments, e *evaluator) (value, error) {
+	e = getBuiltinEvaluator(e, ast.Identifier(c.f.Name))
+	bytesParams := make(map[ast.Identifier]bool, len(c.f.BytesParams))
+	for _, param := range c.f.BytesParams {
+		bytesParams[param] = true
+	}
+	goArgs := make([]interface{}, len(args.positional))
+	for i, arg := range args.positional {
+		v, err := e.evaluate(arg)
+		if err != nil {
+			return nil, err
+		}
+		if bytesParams[c.f.Params[i]] {
+			goArgs[i], err = valueToBytes(e, v)
+		} else {
+			goArgs[i], err = valueToGo(e, v)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	result, err := c.f.Func(goArgs)
+	if err != nil {
+		return nil, e.Error(err.Error())
+	}
+	v, err := valueFromGo(result)
+	if err != nil {
+		return nil, e.Error(fmt.Sprintf("Native function %v returned invalid data: %v", c.f.Name, err))
+	}
+	return v, nil
+}
+
+func (c *nativeCallable) Parameters() ast.Identifiers {
+	return c.f.Params
+}
+
+// builtinNative returns the native function registered under the given name,
+// or null if there is none.
+func builtinNative(e *evaluator, namep potentialValue) (value, error) {
+	name, err := e.evaluateString(namep)
+	if err != nil {
+		return nil, err
+	}
+	f, ok := e.i.evalOpts.nativeFuncs[name.getString()]
+	if !ok {
+		return makeValueNull(), nil
+	}
+	return &valueFunction{ec: &nativeCallable{f}}, nil
+}
+
+type generalBuiltinFunc func(*evaluator, []potentialValue) (value, error)
+
+type generalBuiltinParameter struct {
+	name ast.Identifier
+	// defaultValue is passed when the argument is omitted. nil means the
+	// parameter is required.
+	defaultValue value
+}
+
+// generalBuiltin is a builtin with any number of parameters, of which
+// the trailing ones may be optional.
+type generalBuiltin struct {
+	name       ast.Identifier
+	function   generalBuiltinFunc
+	parameters []generalBuiltinParameter
+}
+
+func (b *generalBuiltin) EvalCall(args callArguments, e *evaluator) (value, error) {
+	// checkArguments made sure that only optional arguments may be missing
+	flatArgs := make([]potentialValue, len(b.parameters))
+	copy(flatArgs, args.positional)
+	for i := len(args.positional); i < len(b.parameters); i++ {
+		flatArgs[i] = &readyValue{b.parameters[i].defaultValue}
+	}
+	return b.function(getBuiltinEvaluator(e, b.name), flatArgs)
+}
+
+func (b *generalBuiltin) Parameters() ast.Identifiers {
+	params := make(ast.Identifiers, len(b.parameters))
+	for i, param := range b.parameters {
+		params[i] = param.name
+	}
+	return params
+}
+
+func (b *generalBuiltin) RequiredParameters() int {
+	for i, param := range b.parameters {
+		if param.defaultValue != nil {
+			return i
+		}
+	}
+	return len(b.parameters)
+}
+
 func todoFunc(e *evaluator, x, y potentialValue) (value, error) {
 	return nil, e.Error("not implemented yet")
 }
@@ -705,35 +2583,118 @@ var uopBuiltins = []*UnaryBuiltin{
 
 // TODO(sbarzowski) eliminate duplication in function names (e.g. build map from array or constants)
 var funcBuiltins = map[string]evalCallable{
-	"extVar":          &UnaryBuiltin{name: "extVar", function: builtinExtVar, parameters: ast.Identifiers{"x"}},
-	"length":          &UnaryBuiltin{name: "length", function: builtinLength, parameters: ast.Identifiers{"x"}},
-	"toString":        &UnaryBuiltin{name: "toString", function: builtinToString, parameters: ast.Identifiers{"x"}},
-	"makeArray":       &BinaryBuiltin{name: "makeArray", function: builtinMakeArray, parameters: ast.Identifiers{"sz", "func"}},
-	"flatMap":         &BinaryBuiltin{name: "flatMap", function: builtinFlatMap, parameters: ast.Identifiers{"func", "arr"}},
-	"filter":          &BinaryBuiltin{name: "filter", function: builtinFilter, parameters: ast.Identifiers{"func", "arr"}},
-	"primitiveEquals": &BinaryBuiltin{name: "primitiveEquals", function: primitiveEquals, parameters: ast.Identifiers{"sz", "func"}},
-	"objectFieldsEx":  &BinaryBuiltin{name: "objectFields", function: builtinObjectFieldsEx, parameters: ast.Identifiers{"obj", "hidden"}},
-	"objectHasEx":     &TernaryBuiltin{name: "objectHasEx", function: builtinObjectHasEx, parameters: ast.Identifiers{"obj", "fname", "hidden"}},
-	"type":            &UnaryBuiltin{name: "type", function: builtinType, parameters: ast.Identifiers{"x"}},
-	"char":            &UnaryBuiltin{name: "char", function: builtinChar, parameters: ast.Identifiers{"x"}},
-	"codepoint":       &UnaryBuiltin{name: "codepoint", function: builtinCodepoint, parameters: ast.Identifiers{"x"}},
-	"ceil":            &UnaryBuiltin{name: "ceil", function: builtinCeil, parameters: ast.Identifiers{"x"}},
-	"floor":           &UnaryBuiltin{name: "floor", function: builtinFloor, parameters: ast.Identifiers{"x"}},
-	"sqrt":            &UnaryBuiltin{name: "sqrt", function: builtinSqrt, parameters: ast.Identifiers{"x"}},
-	"sin":             &UnaryBuiltin{name: "sin", function: builtinSin, parameters: ast.Identifiers{"x"}},
-	"cos":             &UnaryBuiltin{name: "cos", function: builtinCos, parameters: ast.Identifiers{"x"}},
-	"tan":             &UnaryBuiltin{name: "tan", function: builtinTan, parameters: ast.Identifiers{"x"}},
-	"asin":            &UnaryBuiltin{name: "asin", function: builtinAsin, parameters: ast.Identifiers{"x"}},
-	"acos":            &UnaryBuiltin{name: "acos", function: builtinAcos, parameters: ast.Identifiers{"x"}},
-	"atan":            &UnaryBuiltin{name: "atan", function: builtinAtan, parameters: ast.Identifiers{"x"}},
-	"log":             &UnaryBuiltin{name: "log", function: builtinLog, parameters: ast.Identifiers{"x"}},
-	"exp":             &UnaryBuiltin{name: "exp", function: builtinExp, parameters: ast.Identifiers{"x"}},
-	"mantissa":        &UnaryBuiltin{name: "mantissa", function: builtinMantissa, parameters: ast.Identifiers{"x"}},
-	"exponent":        &UnaryBuiltin{name: "exponent", function: builtinExponent, parameters: ast.Identifiers{"x"}},
-	"pow":             &BinaryBuiltin{name: "pow", function: builtinPow, parameters: ast.Identifiers{"base", "exp"}},
-	"modulo":          &BinaryBuiltin{name: "modulo", function: builtinModulo, parameters: ast.Identifiers{"x", "y"}},
-	"md5":             &UnaryBuiltin{name: "md5", function: builtinMd5, parameters: ast.Identifiers{"x"}},
+	"extVar":    &UnaryBuiltin{name: "extVar", function: builtinExtVar, parameters: ast.Identifiers{"x"}},
+	"length":    &UnaryBuiltin{name: "length", function: builtinLength, parameters: ast.Identifiers{"x"}},
+	"toString":  &UnaryBuiltin{name: "toString", function: builtinToString, parameters: ast.Identifiers{"x"}},
+	"parseJson": &UnaryBuiltin{name: "parseJson", function: builtinParseJSON, parameters: ast.Identifiers{"str"}},
+	"parseYaml": &UnaryBuiltin{name: "parseYaml", function: builtinParseYAML, parameters: ast.Identifiers{"str"}},
+	"manifestJsonEx": &generalBuiltin{name: "manifestJsonEx", function: builtinManifestJSONEx, parameters: []generalBuiltinParameter{
+		{name: "value"},
+		{name: "indent"},
+		{name: "compactArrayWidth", defaultValue: makeValueNumber(0)},
+		{name: "preserveOrder", defaultValue: makeValueBoolean(false)},
+		{name: "sortKeys", defaultValue: makeValueNull()},
+		{name: "decimalPlaces", defaultValue: makeValueNull()},
+		{name: "replacer", defaultValue: makeValueNull()},
+	}},
+	"treeGet": &generalBuiltin{name: "treeGet", function: builtinTreeGet, parameters: []generalBuiltinParameter{
+		{name: "obj"},
+		{name: "path"},
+		{name: "sep", defaultValue: makeValueString(".")},
+		{name: "default", defaultValue: makeValueNull()},
+	}},
+	"manifestYamlDoc": &generalBuiltin{name: "manifestYamlDoc", function: builtinManifestYamlDoc, parameters: []generalBuiltinParameter{
+		{name: "value"},
+		{name: "indent_array_in_object", defaultValue: makeValueBoolean(false)},
+		{name: "preserveOrder", defaultValue: makeValueBoolean(false)},
+	}},
+	"objectKeysValues": &generalBuiltin{name: "objectKeysValues", function: builtinObjectKeysValues, parameters: []generalBuiltinParameter{
+		{name: "o"},
+		{name: "preserveOrder", defaultValue: makeValueBoolean(false)},
+	}},
+	"objectKeysValuesAll": &generalBuiltin{name: "objectKeysValuesAll", function: builtinObjectKeysValuesAll, parameters: []generalBuiltinParameter{
+		{name: "o"},
+		{name: "preserveOrder", defaultValue: makeValueBoolean(false)},
+	}},
+	"manifestXmlJsonml": &UnaryBuiltin{name: "manifestXmlJsonml", function: builtinManifestXMLJsonml, parameters: ast.Identifiers{"value"}},
+	"makeArray":         &BinaryBuiltin{name: "makeArray", function: builtinMakeArray, parameters: ast.Identifiers{"sz", "func"}},
+	"trace":             &traceBuiltin{},
+	"coalesce":          &UnaryBuiltin{name: "coalesce", function: builtinCoalesce, parameters: ast.Identifiers{"arr"}},
+	"makeObject":        &BinaryBuiltin{name: "makeObject", function: builtinMakeObject, parameters: ast.Identifiers{"keys", "func"}},
+	"range": &generalBuiltin{name: "range", function: builtinRange, parameters: []generalBuiltinParameter{
+		{name: "from"},
+		{name: "to"},
+		{name: "step", defaultValue: makeValueNumber(1)},
+	}},
+	"reverseRange": &generalBuiltin{name: "reverseRange", function: builtinReverseRange, parameters: []generalBuiltinParameter{
+		{name: "from"},
+		{name: "to"},
+		{name: "step", defaultValue: makeValueNumber(1)},
+	}},
+	"slice": &generalBuiltin{name: "slice", function: builtinSlice, parameters: []generalBuiltinParameter{
+		{name: "indexable"},
+		{name: "index"},
+		{name: "end"},
+		{name: "step"},
+	}},
+	"flatMap":              &BinaryBuiltin{name: "flatMap", function: builtinFlatMap, parameters: ast.Identifiers{"func", "arr"}},
+	"foldl":                &foldBuiltin{name: "foldl", function: builtinFoldl},
+	"foldr":                &foldBuiltin{name: "foldr", function: builtinFoldr},
+	"sum":                  &UnaryBuiltin{name: "sum", function: builtinSum, parameters: ast.Identifiers{"arr"}},
+	"foldUntil":            &TernaryBuiltin{name: "foldUntil", function: builtinFoldUntil, parameters: ast.Identifiers{"func", "arr", "init"}},
+	"filterObject":         &BinaryBuiltin{name: "filterObject", function: builtinFilterObject, parameters: ast.Identifiers{"pred", "obj"}},
+	"pick":                 &BinaryBuiltin{name: "pick", function: builtinPick, parameters: ast.Identifiers{"obj", "keys"}},
+	"withDefaults":         &BinaryBuiltin{name: "withDefaults", function: builtinWithDefaults, parameters: ast.Identifiers{"obj", "defaults"}},
+	"omit":                 &BinaryBuiltin{name: "omit", function: builtinOmit, parameters: ast.Identifiers{"obj", "keys"}},
+	"filter":               &BinaryBuiltin{name: "filter", function: builtinFilter, parameters: ast.Identifiers{"func", "arr"}},
+	"primitiveEquals":      &BinaryBuiltin{name: "primitiveEquals", function: primitiveEquals, parameters: ast.Identifiers{"sz", "func"}},
+	"objectFieldsEx":       &BinaryBuiltin{name: "objectFields", function: builtinObjectFieldsEx, parameters: ast.Identifiers{"obj", "hidden"}},
+	"objectFieldsMode":     &BinaryBuiltin{name: "objectFieldsMode", function: builtinObjectFieldsMode, parameters: ast.Identifiers{"obj", "mode"}},
+	"objectFieldsMatching": &BinaryBuiltin{name: "objectFieldsMatching", function: builtinObjectFieldsMatching, parameters: ast.Identifiers{"obj", "pred"}},
+	"objectValuesEx":       &BinaryBuiltin{name: "objectValuesEx", function: builtinObjectValuesEx, parameters: ast.Identifiers{"obj", "hidden"}},
+	"pluck":                &BinaryBuiltin{name: "pluck", function: builtinPluck, parameters: ast.Identifiers{"key", "arr"}},
+	"mapWithKeyEx":         &TernaryBuiltin{name: "mapWithKeyEx", function: builtinMapWithKeyEx, parameters: ast.Identifiers{"func", "obj", "hidden"}},
+	"objectHasEx":          &TernaryBuiltin{name: "objectHasEx", function: builtinObjectHasEx, parameters: ast.Identifiers{"obj", "fname", "hidden"}},
+	"fieldSource":          &BinaryBuiltin{name: "fieldSource", function: builtinFieldSource, parameters: ast.Identifiers{"obj", "field"}},
+	"fieldIsComputed":      &BinaryBuiltin{name: "fieldIsComputed", function: builtinFieldIsComputed, parameters: ast.Identifiers{"obj", "name"}},
+	"hasPath":              &BinaryBuiltin{name: "hasPath", function: builtinHasPath, parameters: ast.Identifiers{"obj", "path"}},
+	"invertObject":         &UnaryBuiltin{name: "invertObject", function: builtinInvertObject, parameters: ast.Identifiers{"obj"}},
+	"native":               &UnaryBuiltin{name: "native", function: builtinNative, parameters: ast.Identifiers{"name"}},
+	"type":                 &UnaryBuiltin{name: "type", function: builtinType, parameters: ast.Identifiers{"x"}},
+	"char":                 &UnaryBuiltin{name: "char", function: builtinChar, parameters: ast.Identifiers{"x"}},
+	"codepoint":            &UnaryBuiltin{name: "codepoint", function: builtinCodepoint, parameters: ast.Identifiers{"x"}},
+	"ceil":                 &UnaryBuiltin{name: "ceil", function: builtinCeil, parameters: ast.Identifiers{"x"}},
+	"floor":                &UnaryBuiltin{name: "floor", function: builtinFloor, parameters: ast.Identifiers{"x"}},
+	"sqrt":                 &UnaryBuiltin{name: "sqrt", function: builtinSqrt, parameters: ast.Identifiers{"x"}},
+	"sin":                  &UnaryBuiltin{name: "sin", function: builtinSin, parameters: ast.Identifiers{"x"}},
+	"cos":                  &UnaryBuiltin{name: "cos", function: builtinCos, parameters: ast.Identifiers{"x"}},
+	"tan":                  &UnaryBuiltin{name: "tan", function: builtinTan, parameters: ast.Identifiers{"x"}},
+	"asin":                 &UnaryBuiltin{name: "asin", function: builtinAsin, parameters: ast.Identifiers{"x"}},
+	"acos":                 &UnaryBuiltin{name: "acos", function: builtinAcos, parameters: ast.Identifiers{"x"}},
+	"atan":                 &UnaryBuiltin{name: "atan", function: builtinAtan, parameters: ast.Identifiers{"x"}},
+	"log":                  &UnaryBuiltin{name: "log", function: builtinLog, parameters: ast.Identifiers{"x"}},
+	"exp":                  &UnaryBuiltin{name: "exp", function: builtinExp, parameters: ast.Identifiers{"x"}},
+	"mantissa":             &UnaryBuiltin{name: "mantissa", function: builtinMantissa, parameters: ast.Identifiers{"x"}},
+	"exponent":             &UnaryBuiltin{name: "exponent", function: builtinExponent, parameters: ast.Identifiers{"x"}},
+	"pow":                  &BinaryBuiltin{name: "pow", function: builtinPow, parameters: ast.Identifiers{"base", "exp"}},
+	"modulo":               &BinaryBuiltin{name: "modulo", function: builtinModulo, parameters: ast.Identifiers{"x", "y"}},
+	"mod":                  &BinaryBuiltin{name: "mod", function: builtinMod, parameters: ast.Identifiers{"a", "b"}},
+	"split":                &BinaryBuiltin{name: "split", function: builtinSplit, parameters: ast.Identifiers{"str", "c"}},
+	"splitLimit":           &TernaryBuiltin{name: "splitLimit", function: builtinSplitLimit, parameters: ast.Identifiers{"str", "c", "maxsplits"}},
+	"md5":                  &UnaryBuiltin{name: "md5", function: builtinMd5, parameters: ast.Identifiers{"x"}, pure: true},
+	"base64":               &UnaryBuiltin{name: "base64", function: builtinBase64, parameters: ast.Identifiers{"input"}},
+	"splitWhitespace":      &UnaryBuiltin{name: "splitWhitespace", function: builtinSplitWhitespace, parameters: ast.Identifiers{"str"}},
+	"words":                &UnaryBuiltin{name: "words", function: builtinWords, parameters: ast.Identifiers{"str"}},
+	"capitalize":           &UnaryBuiltin{name: "capitalize", function: builtinCapitalize, parameters: ast.Identifiers{"str"}},
+	"title":                &UnaryBuiltin{name: "title", function: builtinTitle, parameters: ast.Identifiers{"str"}},
+	"stripChars":           &BinaryBuiltin{name: "stripChars", function: builtinStripChars, parameters: ast.Identifiers{"str", "chars"}},
+	"lstripChars":          &BinaryBuiltin{name: "lstripChars", function: builtinLstripChars, parameters: ast.Identifiers{"str", "chars"}},
+	"rstripChars":          &BinaryBuiltin{name: "rstripChars", function: builtinRstripChars, parameters: ast.Identifiers{"str", "chars"}},
+	"stripMargin": &generalBuiltin{name: "stripMargin", function: builtinStripMargin, parameters: []generalBuiltinParameter{
+		{name: "str"},
+		{name: "marginChar", defaultValue: makeValueString("|")},
+	}},
 
 	// internal
-	"$objectFlatMerge": &UnaryBuiltin{name: "$objectFlatMerge", function: builtinUglyObjectFlatMerge, parameters: ast.Identifiers{"x"}},
+	"$objectFlatMerge": &objectFlatMergeBuiltin{name: "$objectFlatMerge"},
 }
@@ -109,6 +109,8 @@ func desugarFields(location ast.LocationRange, fields *ast.ObjectFields, objLeve
 	}
 
 	// Simplify asserts
+	// The message is only in the failure branch, so it is evaluated only when
+	// the assertion fails.
 	// TODO(dcunnin): this
 	for i := range *fields {
 		field := &(*fields)[i]
@@ -161,14 +163,22 @@ func desugarFields(location ast.LocationRange, fields *ast.ObjectFields, objLeve
 		if field.Kind == ast.ObjectLocal {
 			continue
 		}
-		if len(binds) > 0 {
+		if len(binds) > 0 && field.Kind == ast.ObjectAssert {
+			// The locals go inside, so that the assertion stays a
+			// conditional and a failure can be told apart from other errors.
+			assertion := field.Expr2.(*ast.Conditional)
+			assertion.Cond = &ast.Local{NodeBase: ast.NewNodeBaseLoc(*assertion.Cond.Loc()), Binds: binds, Body: assertion.Cond}
+			onFailure := assertion.BranchFalse.(*ast.Error)
+			onFailure.Expr = &ast.Local{NodeBase: ast.NewNodeBaseLoc(*onFailure.Expr.Loc()), Binds: binds, Body: onFailure.Expr}
+		} else if len(binds) > 0 {
 			field.Expr2 = &ast.Local{ast.NewNodeBaseLoc(*field.Expr2.Loc()), binds, field.Expr2}
 		}
 		newFields = append(newFields, field)
 	}
 	*fields = newFields
 
-	// Change all to FIELD_EXPR
+	// Change all to FIELD_EXPR, except that identifier fields keep their kind
+	// so that the object can remember which names were not computed.
 	for i := range *fields {
 		field := &(*fields)[i]
 		switch field.Kind {
@@ -177,7 +187,6 @@ func desugarFields(location ast.LocationRange, fields *ast.ObjectFields, objLeve
 
 		case ast.ObjectFieldID:
 			field.Expr1 = makeStr(string(*field.Id))
-			field.Kind = ast.ObjectFieldExpr
 
 		case ast.ObjectFieldExpr:
 		// Nothing to do.
@@ -260,6 +269,8 @@ func desugarObjectComp(comp *ast.ObjectComp, objLevel int) (ast.Node, error) {
 	}
 
 	desugaredComp := buildStdCall("$objectFlatMerge", desugaredArrayComp)
+	// Errors about duplicate fields refer to the location of the comprehension.
+	desugaredComp.(*ast.Apply).NodeBase = comp.NodeBase
 	return desugaredComp, nil
 }
 
@@ -293,8 +304,14 @@ func buildDesugaredObject(nodeBase ast.NodeBase, fields ast.ObjectFields) *ast.D
 	for _, field := range fields {
 		if field.Kind == ast.ObjectAssert {
 			newAsserts = append(newAsserts, field.Expr2)
-		} else if field.Kind == ast.ObjectFieldExpr {
-			newFields = append(newFields, ast.DesugaredObjectField{field.Hide, field.Expr1, field.Expr2, field.SuperSugar})
+		} else if field.Kind == ast.ObjectFieldExpr || field.Kind == ast.ObjectFieldID {
+			newFields = append(newFields, ast.DesugaredObjectField{
+				Hide:      field.Hide,
+				Name:      field.Expr1,
+				Body:      field.Expr2,
+				PlusSuper: field.SuperSugar,
+				Computed:  field.Kind != ast.ObjectFieldID,
+			})
 		} else {
 			panic(fmt.Sprintf("INTERNAL ERROR: field should have been desugared: %s", field.Kind))
 		}
@@ -364,6 +381,7 @@ func desugar(astPtr *ast.Node, objLevel int) (err error) {
 		}
 
 	case *ast.Assert:
+		// As with object asserts, the message is only evaluated on failure.
 		if node.Message == nil {
 			node.Message = buildLiteralString("Assertion failed")
 		}
@@ -21,6 +21,8 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"strings"
+	"unicode/utf8"
 )
 
 type ImportedData struct {
@@ -29,10 +31,43 @@ type ImportedData struct {
 	content   string
 }
 
+// MakeImportedData returns the result of a successful import. foundHere
+// identifies the imported file, see Importer.
+func MakeImportedData(content, foundHere string) *ImportedData {
+	return &ImportedData{content: content, foundHere: foundHere}
+}
+
+// MakeImportedDataError returns the result of a failed import.
+func MakeImportedDataError(err error) *ImportedData {
+	return &ImportedData{err: err}
+}
+
+// Importer resolves and loads imported files.
+//
+// codeDir is the directory of the file containing the import (import,
+// importstr), so that relative imports are resolved relative to the importing
+// file rather than the current working directory or the main file.
+// The location of the imported file (foundHere) becomes the file name of the
+// imported code, so imports within it are in turn relative to it.
+//
+// foundHere doesn't have to be a file path. If it is a URL, e.g.
+// "https://example.com/lib/a.libsonnet", it is left as is and codeDir is
+// everything up to and including its last slash, "https://example.com/lib/",
+// so that an importer can resolve relative imports with
+// net/url.URL.ResolveReference.
 type Importer interface {
 	Import(codeDir string, importedPath string) *ImportedData
 }
 
+// importDir returns the codeDir passed to the Importer for imports within
+// the given file, see Importer.
+func importDir(fileName string) string {
+	if strings.Contains(fileName, "://") {
+		return fileName[:strings.LastIndex(fileName, "/")+1]
+	}
+	return path.Dir(fileName)
+}
+
 type ImportCacheValue struct {
 	data *ImportedData
 
@@ -73,9 +108,27 @@ func (cache *ImportCache) ImportString(codeDir, importedPath string, e *evaluato
 	if data.data.err != nil {
 		return nil, e.Error(data.data.err.Error())
 	}
+	// Jsonnet strings are sequences of characters, so arbitrary bytes can't be
+	// represented. Fail rather than replacing them with U+FFFD.
+	if offset := invalidUTF8Offset(data.data.content); offset >= 0 {
+		return nil, e.Error(fmt.Sprintf("importstr %#v: the file is not valid UTF-8, invalid byte at offset %d", importedPath, offset))
+	}
 	return makeValueString(data.data.content), nil
 }
 
+// invalidUTF8Offset returns the offset of the first byte of s which is not
+// part of a valid UTF-8 sequence, or -1 if s is valid UTF-8.
+func invalidUTF8Offset(s string) int {
+	for offset, r := range s {
+		if r == utf8.RuneError {
+			if _, size := utf8.DecodeRuneInString(s[offset:]); size == 1 {
+				return offset
+			}
+		}
+	}
+	return -1
+}
+
 func codeToPV(e *evaluator, filename string, code string) potentialValue {
 	node, err := snippetToAST(filename, code)
 	if err != nil {
@@ -106,6 +159,11 @@ func (cache *ImportCache) ImportCode(codeDir, importedPath string, e *evaluator)
 type FileImporter struct {
 	// TODO(sbarzowski) fill it in
 	JPaths []string
+	// Extensions (e.g. ".libsonnet") tried when the imported path doesn't
+	// exist, so that it may be written without one. The path as written
+	// always takes precedence. If it doesn't exist and more than one
+	// extension matches in the same directory, the import is ambiguous.
+	Extensions []string
 }
 
 func tryPath(dir, importedPath string) (found bool, content []byte, foundHere string, err error) {
@@ -122,14 +180,39 @@ func tryPath(dir, importedPath string) (found bool, content []byte, foundHere st
 	return true, content, absPath, err
 }
 
+// tryDir looks for importedPath in dir, trying the extensions if it doesn't
+// exist as is.
+func (importer *FileImporter) tryDir(dir, importedPath string) (found bool, content []byte, foundHere string, err error) {
+	found, content, foundHere, err = tryPath(dir, importedPath)
+	if found || err != nil {
+		return
+	}
+	var candidates []string
+	for _, ext := range importer.Extensions {
+		extFound, extContent, extFoundHere, err := tryPath(dir, importedPath+ext)
+		if err != nil {
+			return false, nil, "", err
+		}
+		if extFound {
+			found, content, foundHere = true, extContent, extFoundHere
+			candidates = append(candidates, extFoundHere)
+		}
+	}
+	if len(candidates) > 1 {
+		return false, nil, "", fmt.Errorf("Import %#v is ambiguous, it could be any of: %s",
+			importedPath, strings.Join(candidates, ", "))
+	}
+	return found, content, foundHere, nil
+}
+
 func (importer *FileImporter) Import(dir, importedPath string) *ImportedData {
-	found, content, foundHere, err := tryPath(dir, importedPath)
+	found, content, foundHere, err := importer.tryDir(dir, importedPath)
 	if err != nil {
 		return &ImportedData{err: err}
 	}
 
 	for i := 0; !found && i < len(importer.JPaths); i++ {
-		found, content, foundHere, err = tryPath(importer.JPaths[i], importedPath)
+		found, content, foundHere, err = importer.tryDir(importer.JPaths[i], importedPath)
 		if err != nil {
 			return &ImportedData{err: err}
 		}
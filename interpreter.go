@@ -18,11 +18,17 @@ package jsonnet
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"math"
-	"path"
 	"reflect"
-	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	"github.com/google/go-jsonnet/ast"
 )
@@ -74,17 +80,19 @@ type callFrame struct {
 	// Tracing information about the place where (TODO)
 	trace *TraceElement
 
-	/** Reuse this stack frame for the purpose of tail call optimization. */
-	tailCall bool // TODO what is it?
+	// True if the frame belongs to a function called with tailstrict. Such a
+	// frame is dropped when its body makes another call in tail position, so
+	// tail recursion runs in bounded stack.
+	trimmable bool
 
 	env environment
 }
 
 func dumpCallFrame(c *callFrame) string {
-	return fmt.Sprintf("<callFrame isCall = %t location = %v tailCall = %t>",
+	return fmt.Sprintf("<callFrame isCall = %t location = %v trimmable = %t>",
 		c.isCall,
 		*c.trace.loc,
-		c.tailCall,
+		c.trimmable,
 	)
 }
 
@@ -115,14 +123,21 @@ func (s *callStack) pop() {
 	s.stack = s.stack[:len(s.stack)-1]
 }
 
-// TODO(sbarzowski) I don't get this. When we have a tail call why can't we just
-// pop the last call from stack before pushing our new thing.
-// https://github.com/google/go-jsonnet/pull/24#pullrequestreview-58524217
-/** If there is a tailstrict annotated frame followed by some locals, pop them all. */
+// popIfExists pops the frame that was pushed when the stack had the given
+// size, unless it was already removed by tailCallTrimStack.
+func (s *callStack) popIfExists(whichFrame int) {
+	if len(s.stack) == whichFrame {
+		s.pop()
+	}
+}
+
+// tailCallTrimStack is called before a call in tail position. If the current
+// call frame is trimmable, nothing is left to do in it once the new call
+// returns, so the frame and the locals above it are popped right away.
 func (s *callStack) tailCallTrimStack() {
 	for i := len(s.stack) - 1; i >= 0; i-- {
 		if s.stack[i].isCall {
-			if !s.stack[i].tailCall { // TODO(sbarzowski) we may need to check some more stuff
+			if !s.stack[i].trimmable {
 				return
 			}
 			// Remove this stack frame and everything above it
@@ -133,18 +148,17 @@ func (s *callStack) tailCallTrimStack() {
 	}
 }
 
-func (i *interpreter) newCall(trace *TraceElement, env environment) error {
+func (i *interpreter) newCall(trace *TraceElement, env environment, trimmable bool) error {
 	s := &i.stack
-	s.tailCallTrimStack()
 	if s.calls >= s.limit {
 		// TODO(sbarzowski) add tracing information
 		return makeRuntimeError("Max stack frames exceeded.", i.getCurrentStackTrace(trace))
 	}
 	s.stack = append(s.stack, &callFrame{
-		isCall:   true,
-		trace:    trace,
-		env:      env,
-		tailCall: false,
+		isCall:    true,
+		trace:     trace,
+		env:       env,
+		trimmable: trimmable,
 	})
 	s.calls++
 	return nil
@@ -207,20 +221,100 @@ type interpreter struct {
 
 	// Keeps imports
 	importCache *ImportCache
+
+	// Settings of evaluation, from the VM
+	evalOpts evaluationOptions
+
+	// Settings of manifestation, from the VM
+	manifestOpts manifestationOptions
+
+	// std.manifestOmit, which a std.manifestJsonEx replacer returns to omit
+	// a value. It is recognized by identity.
+	manifestOmit valueObject
+
+	// Results of pure builtins, see UnaryBuiltin.evalCached
+	builtinCache map[builtinCacheKey]value
+}
+
+// evaluationOptions are the VM settings which affect evaluation.
+type evaluationOptions struct {
+	// Allow negative slice indices, counting from the end (not in upstream)
+	negativeSliceIndices bool
+	// Where std.trace writes its messages, nil if tracing is disabled
+	traceOut io.Writer
+	// Write std.trace messages as JSON lines
+	traceJSON bool
+	// Indent std.trace messages by the depth of the call stack
+	traceIndent bool
+	// Functions available through std.native
+	nativeFuncs map[string]*NativeFunction
+	// Evaluate locals, function arguments and array elements eagerly, in
+	// source order
+	strict bool
+	// Make std.parseJson fail on integers which can't be represented exactly
+	exactJSONIntegers bool
+	// Reject bitwise operands which are not safe integers instead of
+	// truncating them
+	strictBitwise bool
+	// Maximum number of elements made by std.range and std.reverseRange, 0
+	// for no limit
+	maxRangeLength int
+}
+
+// manifestationOptions are the VM settings which affect manifested output.
+type manifestationOptions struct {
+	// Maximum nesting of arrays and objects in manifested values
+	maxDepth int
+	// Maximum size of the output in bytes, 0 for no limit
+	maxOutputSize int
+	// Escape all non-ASCII characters in strings of the final output
+	asciiOutput bool
+	// Format numbers exactly like the C++ implementation
+	upstreamNumbers bool
+	// Overrides the escaping of characters in strings of the final output,
+	// if set
+	escapeRune func(rune) (string, bool)
+	// End lines of the output with \r\n instead of \n
+	crlf bool
+	// Start the output with a UTF-8 byte order mark
+	bom bool
+	// Comment the top-level fields with the locations of their definitions
+	annotateSources bool
 }
 
 // Build a binding frame containing specified variables.
 func (i *interpreter) capture(freeVars ast.Identifiers) bindingFrame {
-	env := make(bindingFrame)
+	env := make(bindingFrame, len(freeVars))
 	for _, fv := range freeVars {
-		env[fv] = i.stack.lookUpVar(fv)
-		if env[fv] == nil {
+		th := i.stack.lookUpVar(fv)
+		if th == nil {
 			panic(fmt.Sprintf("Variable %v vanished", fv))
 		}
+		env[fv] = th
 	}
 	return env
 }
 
+// immediatePotentialValue returns a potentialValue for node without creating
+// a thunk (and capturing the environment for it) if that is not necessary,
+// i.e. for variables, which are already bound to potentialValues, and for
+// literals. Otherwise it returns nil.
+func (i *interpreter) immediatePotentialValue(node ast.Node) potentialValue {
+	switch node := node.(type) {
+	case *ast.Var:
+		return i.stack.lookUpVar(node.Id)
+	case *ast.LiteralNumber:
+		return &readyValue{makeValueNumber(node.Value)}
+	case *ast.LiteralString:
+		return &readyValue{makeValueString(node.Value)}
+	case *ast.LiteralBoolean:
+		return &readyValue{makeValueBoolean(node.Value)}
+	case *ast.LiteralNull:
+		return &readyValue{makeValueNull()}
+	}
+	return nil
+}
+
 func addBindings(a, b bindingFrame) bindingFrame {
 	result := make(bindingFrame)
 
@@ -242,7 +336,16 @@ func (i *interpreter) getCurrentEnv(ast ast.Node) environment {
 	)
 }
 
-func (i *interpreter) evaluate(a ast.Node, context *TraceContext) (value, error) {
+// tailCallStatus tells whether an expression is in tail position, i.e. its
+// value is the value of the innermost call frame.
+type tailCallStatus int
+
+const (
+	nonTailCall tailCallStatus = iota
+	tailCall
+)
+
+func (i *interpreter) evaluate(a ast.Node, context *TraceContext, tc tailCallStatus) (value, error) {
 	// TODO(dcunnin): All the other cases...
 
 	e := &evaluator{
@@ -262,17 +365,32 @@ func (i *interpreter) evaluate(a ast.Node, context *TraceContext) (value, error)
 			elThunk := makeThunk("array_element", env, el)
 			elements = append(elements, elThunk)
 		}
+		if i.evalOpts.strict {
+			for _, el := range elements {
+				if _, err := e.evaluate(el); err != nil {
+					return nil, err
+				}
+			}
+		}
 		return makeValueArray(elements), nil
 
 	case *ast.Binary:
 		// Some binary operators are lazy, so thunks are needed in general
-		env := i.getCurrentEnv(ast)
 		// TODO(sbarzowski) make sure it displays nicely in stack trace (thunk names etc.)
 		// TODO(sbarzowski) it may make sense not to show a line in stack trace for operators
 		// 					at all in many cases. 1 + 2 + 3 + 4 + error "x" will show 5 lines
 		//					of stack trace now, and it's not that nice.
-		left := makeThunk("x", env, ast.Left)
-		right := makeThunk("y", env, ast.Right)
+		left := i.immediatePotentialValue(ast.Left)
+		right := i.immediatePotentialValue(ast.Right)
+		if left == nil || right == nil {
+			env := i.getCurrentEnv(ast)
+			if left == nil {
+				left = makeThunk("x", env, ast.Left)
+			}
+			if right == nil {
+				right = makeThunk("y", env, ast.Right)
+			}
+		}
 
 		builtin := bopBuiltins[ast.Op]
 
@@ -304,9 +422,9 @@ func (i *interpreter) evaluate(a ast.Node, context *TraceContext) (value, error)
 			return nil, err
 		}
 		if condBool.value {
-			return e.evalInCurrentContext(ast.BranchTrue)
+			return i.evaluate(ast.BranchTrue, context, tc)
 		}
-		return e.evalInCurrentContext(ast.BranchFalse)
+		return i.evaluate(ast.BranchFalse, context, tc)
 
 	case *ast.DesugaredObject:
 		// Evaluate all the field names.  Check for null, dups, etc.
@@ -334,11 +452,11 @@ func (i *interpreter) evaluate(a ast.Node, context *TraceContext) (value, error)
 			if field.PlusSuper {
 				f = &PlusSuperUnboundField{f}
 			}
-			fields[fieldName] = valueSimpleObjectField{field.Hide, f}
+			fields[fieldName] = valueSimpleObjectField{field.Hide, f, len(fields), field.Computed}
 		}
-		var asserts []unboundField
+		var asserts []objectAssert
 		for _, assert := range ast.Asserts {
-			asserts = append(asserts, &codeUnboundField{assert})
+			asserts = append(asserts, makeObjectAssert(assert))
 		}
 		upValues := i.capture(ast.FreeVariables())
 		return makeValueSimpleObject(upValues, fields, asserts), nil
@@ -380,11 +498,11 @@ func (i *interpreter) evaluate(a ast.Node, context *TraceContext) (value, error)
 		return nil, e.Error(fmt.Sprintf("Value non indexable: %v", reflect.TypeOf(targetValue)))
 
 	case *ast.Import:
-		codeDir := path.Dir(ast.Loc().FileName)
+		codeDir := importDir(ast.Loc().FileName)
 		return i.importCache.ImportCode(codeDir, ast.File, e)
 
 	case *ast.ImportStr:
-		codeDir := path.Dir(ast.Loc().FileName)
+		codeDir := importDir(ast.Loc().FileName)
 		return i.importCache.ImportString(codeDir, ast.File, e)
 
 	case *ast.LiteralBoolean:
@@ -410,10 +528,19 @@ func (i *interpreter) evaluate(a ast.Node, context *TraceContext) (value, error)
 			bindEnv.upValues[bind.Variable] = th
 		}
 		i.newLocal(vars)
+		stackSize := len(i.stack.stack)
+		if i.evalOpts.strict {
+			for _, bind := range ast.Binds {
+				if _, err := e.evaluate(vars[bind.Variable]); err != nil {
+					i.stack.popIfExists(stackSize)
+					return nil, err
+				}
+			}
+		}
 		// Add new stack frame, with new thunk for this variable
 		// execute body WRT stack frame.
-		v, err := e.evalInCurrentContext(ast.Body)
-		i.stack.pop()
+		v, err := i.evaluate(ast.Body, context, tc)
+		i.stack.popIfExists(stackSize)
 		return v, err
 
 	case *ast.Self:
@@ -432,7 +559,11 @@ func (i *interpreter) evaluate(a ast.Node, context *TraceContext) (value, error)
 		if err != nil {
 			return nil, err
 		}
-		return objectIndex(e, i.stack.getSelfBinding().super(), indexStr.getString())
+		sb := i.stack.getSelfBinding().super()
+		if sb.superDepth >= sb.self.inheritanceSize() {
+			return nil, e.Error("Attempt to use super when there is no super class.")
+		}
+		return objectIndex(e, sb, indexStr.getString())
 
 	case *ast.InSuper:
 		index, err := e.evalInCurrentContext(ast.Index)
@@ -443,8 +574,8 @@ func (i *interpreter) evaluate(a ast.Node, context *TraceContext) (value, error)
 		if err != nil {
 			return nil, err
 		}
-		field := tryObjectIndex(i.stack.getSelfBinding().super(), indexStr.getString(), withHidden)
-		return makeValueBoolean(field != nil), nil
+		hasField := objectHasField(i.stack.getSelfBinding().super(), indexStr.getString(), withHidden)
+		return makeValueBoolean(hasField), nil
 
 	case *ast.Function:
 		return &valueFunction{
@@ -462,17 +593,38 @@ func (i *interpreter) evaluate(a ast.Node, context *TraceContext) (value, error)
 			return nil, err
 		}
 
-		// environment in which we can evaluate arguments
-		argEnv := i.getCurrentEnv(a)
-
 		arguments := callArguments{
 			positional: make([]potentialValue, len(ast.Arguments.Positional)),
 		}
-		for i, arg := range ast.Arguments.Positional {
+		// environment in which we can evaluate arguments, captured only
+		// if some argument needs a thunk
+		var argEnv *environment
+		for index, arg := range ast.Arguments.Positional {
+			if pv := i.immediatePotentialValue(arg); pv != nil {
+				arguments.positional[index] = pv
+				continue
+			}
+			if argEnv == nil {
+				env := i.getCurrentEnv(a)
+				argEnv = &env
+			}
 			// TODO(sbarzowski) better thunk name
-			arguments.positional[i] = makeThunk("arg", argEnv, arg)
+			arguments.positional[index] = makeThunk("arg", *argEnv, arg)
 		}
 
+		if ast.TailStrict || i.evalOpts.strict {
+			// Force the arguments, so that they don't keep the caller's
+			// frame alive and don't build up a chain of thunks.
+			for _, arg := range arguments.positional {
+				if _, err := e.evaluate(arg); err != nil {
+					return nil, err
+				}
+			}
+			arguments.tailstrict = ast.TailStrict
+		}
+		if tc == tailCall {
+			i.stack.tailCallTrimStack()
+		}
 		return e.evaluate(function.call(arguments))
 
 	default:
@@ -482,41 +634,122 @@ func (i *interpreter) evaluate(a ast.Node, context *TraceContext) (value, error)
 
 // unparseString Wraps in "" and escapes stuff to make the string JSON-compliant and human-readable.
 func unparseString(v string) string {
+	return unparseStringEx(v, false, nil)
+}
+
+// unparseStringEx is like unparseString, but if asciiOnly is set it also
+// escapes all non-ASCII characters, so that the result is plain ASCII.
+// Characters outside the Basic Multilingual Plane become UTF-16 surrogate
+// pairs, as JSON requires. If escapeRune is not nil, it is consulted first
+// for every character and the characters it accepts are written as it says.
+func unparseStringEx(v string, asciiOnly bool, escapeRune func(rune) (string, bool)) string {
 	var buf bytes.Buffer
+	writeUnparsedString(&buf, v, asciiOnly, escapeRune)
+	return buf.String()
+}
+
+// unparsedWriter is where the writeUnparsed functions write, a bytes.Buffer
+// or a manifestBuffer.
+type unparsedWriter interface {
+	io.Writer
+	WriteString(s string) (int, error)
+	WriteRune(r rune) (int, error)
+}
+
+// writeUnparsedString writes the result of unparseStringEx to buf, without
+// building an intermediate string.
+func writeUnparsedString(buf unparsedWriter, v string, asciiOnly bool, escapeRune func(rune) (string, bool)) {
 	buf.WriteString("\"")
 	for _, c := range v {
+		writeUnparsedRune(buf, c, asciiOnly, escapeRune)
+	}
+	buf.WriteString("\"")
+}
+
+// htmlEscapeRune extends escapeRune, which may be nil, to escape the
+// characters which are special in HTML.
+func htmlEscapeRune(escapeRune func(rune) (string, bool)) func(rune) (string, bool) {
+	return func(c rune) (string, bool) {
 		switch c {
-		case '"':
-			buf.WriteString("\\\"")
-		case '\\':
-			buf.WriteString("\\\\")
-		case '\b':
-			buf.WriteString("\\b")
-		case '\f':
-			buf.WriteString("\\f")
-		case '\n':
-			buf.WriteString("\\n")
-		case '\r':
-			buf.WriteString("\\r")
-		case '\t':
-			buf.WriteString("\\t")
-		case 0:
-			buf.WriteString("\\u0000")
-		default:
-			if c < 0x20 || (c >= 0x7f && c <= 0x9f) {
-				buf.WriteString(fmt.Sprintf("\\u%04x", int(c)))
+		case '<', '>', '&':
+			return fmt.Sprintf("\\u%04x", c), true
+		}
+		if escapeRune != nil {
+			return escapeRune(c)
+		}
+		return "", false
+	}
+}
+
+func writeUnparsedRune(buf unparsedWriter, c rune, asciiOnly bool, escapeRune func(rune) (string, bool)) {
+	if escapeRune != nil {
+		if escaped, ok := escapeRune(c); ok {
+			buf.WriteString(escaped)
+			return
+		}
+	}
+	switch c {
+	case '"':
+		buf.WriteString("\\\"")
+	case '\\':
+		buf.WriteString("\\\\")
+	case '\b':
+		buf.WriteString("\\b")
+	case '\f':
+		buf.WriteString("\\f")
+	case '\n':
+		buf.WriteString("\\n")
+	case '\r':
+		buf.WriteString("\\r")
+	case '\t':
+		buf.WriteString("\\t")
+	case 0:
+		buf.WriteString("\\u0000")
+	default:
+		if c < 0x20 || (c >= 0x7f && c <= 0x9f) {
+			fmt.Fprintf(buf, "\\u%04x", int(c))
+		} else if asciiOnly && c > 0x7f {
+			if r1, r2 := utf16.EncodeRune(c); r1 != utf8.RuneError {
+				fmt.Fprintf(buf, "\\u%04x\\u%04x", r1, r2)
 			} else {
-				buf.WriteRune(c)
+				fmt.Fprintf(buf, "\\u%04x", c)
 			}
+		} else {
+			buf.WriteRune(c)
 		}
 	}
+}
+
+// writeUnparsedValueString is like writeUnparsedString, but it writes the
+// runes of a Jsonnet string directly, without converting them to a Go string.
+func writeUnparsedValueString(buf unparsedWriter, v *valueString, asciiOnly bool, escapeRune func(rune) (string, bool)) {
+	buf.WriteString("\"")
+	for _, c := range v.value {
+		writeUnparsedRune(buf, c, asciiOnly, escapeRune)
+	}
 	buf.WriteString("\"")
-	return buf.String()
 }
 
+// unparseNumber formats numbers in the shortest form which parses back to
+// the same number. It only relies on strconv, which implements the formatting
+// itself rather than using the platform's, so the output is the same on every
+// platform, e.g. integers are written in full and small or large non-integers
+// with an exponent of at least two digits, like 1e-07 or 1.2345675e+06.
 func unparseNumber(v float64) string {
 	if v == math.Floor(v) {
-		return fmt.Sprintf("%.0f", v)
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	}
+
+	// The shortest representation which parses back to the same number,
+	// e.g. 0.1 rather than 0.10000000000000001.
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// unparseNumberUpstream formats numbers like the C++ implementation does,
+// i.e. with std::setprecision(17) for non-integers.
+func unparseNumberUpstream(v float64) string {
+	if v == math.Floor(v) {
+		return strconv.FormatFloat(v, 'f', 0, 64)
 	}
 
 	// See "What Every Computer Scientist Should Know About Floating-Point Arithmetic"
@@ -525,27 +758,418 @@ func unparseNumber(v float64) string {
 	return fmt.Sprintf("%.17g", v)
 }
 
+// unparseOutputNumber formats a number in manifested output.
+func (i *interpreter) unparseOutputNumber(v float64) string {
+	if i.manifestOpts.upstreamNumbers {
+		return unparseNumberUpstream(v)
+	}
+	return unparseNumber(v)
+}
+
+// manifestPath is the location of a value being manifested, relative to the
+// manifested root. It is used for error messages. It is a linked list, so
+// each nested value costs one small allocation and the path is only turned
+// into a string if there is an error. nil is the root.
+type manifestPath struct {
+	parent  *manifestPath
+	field   string
+	index   int
+	isIndex bool
+	depth   int
+}
+
+func (p *manifestPath) getDepth() int {
+	if p == nil {
+		return 0
+	}
+	return p.depth
+}
+
+func (p *manifestPath) withField(field string) *manifestPath {
+	return &manifestPath{parent: p, field: field, depth: p.getDepth() + 1}
+}
+
+func (p *manifestPath) withIndex(index int) *manifestPath {
+	return &manifestPath{parent: p, index: index, isIndex: true, depth: p.getDepth() + 1}
+}
+
+func isManifestPathIdentifier(field string) bool {
+	for i, r := range field {
+		isLetter := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		if !isLetter && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return field != ""
+}
+
+func (p *manifestPath) writeTo(buf *bytes.Buffer) {
+	if p == nil {
+		return
+	}
+	p.parent.writeTo(buf)
+	if p.isIndex {
+		fmt.Fprintf(buf, "[%d]", p.index)
+	} else if isManifestPathIdentifier(p.field) {
+		if p.parent != nil {
+			buf.WriteString(".")
+		}
+		buf.WriteString(p.field)
+	} else {
+		buf.WriteString("[")
+		buf.WriteString(unparseString(p.field))
+		buf.WriteString("]")
+	}
+}
+
+// String returns the path in Jsonnet indexing syntax, e.g. a.b[0]["c d"].
+func (p *manifestPath) String() string {
+	var buf bytes.Buffer
+	p.writeTo(&buf)
+	return buf.String()
+}
+
+// at returns the path for the end of an error message, e.g. " at path a.b",
+// or an empty string for the root.
+func (p *manifestPath) at() string {
+	if p == nil {
+		return ""
+	}
+	return " at path " + p.String()
+}
+
+// checkManifestDepth prevents unbounded recursion when manifesting pathological
+// (e.g. self-referential) structures.
+func (i *interpreter) checkManifestDepth(trace *TraceElement, v value, path *manifestPath) error {
+	switch v.(type) {
+	case *valueArray, valueObject:
+		if path.getDepth() >= i.manifestOpts.maxDepth {
+			e := &evaluator{i: i, trace: trace}
+			return e.Error("manifestation exceeded maximum depth")
+		}
+	}
+	return nil
+}
+
+// manifestFunctionError reports an attempt to manifest a function, which has
+// no representation in the output format.
+func (i *interpreter) manifestFunctionError(trace *TraceElement, format string, path *manifestPath) error {
+	msg := "Couldn't manifest function as " + format + path.at()
+	return makeRuntimeError(msg, i.getCurrentStackTrace(trace))
+}
+
+// makeObjectAssert splits a desugared object assertion, i.e.
+// if cond then true else error msg, into the condition and the message.
+func makeObjectAssert(node ast.Node) objectAssert {
+	if conditional, ok := node.(*ast.Conditional); ok {
+		if failure, ok := conditional.BranchFalse.(*ast.Error); ok {
+			return objectAssert{
+				cond: &codeUnboundField{conditional.Cond},
+				msg:  &codeUnboundField{failure.Expr},
+				loc:  failure.Loc(),
+			}
+		}
+	}
+	return objectAssert{cond: &codeUnboundField{node}}
+}
+
+// checkManifestedAssertions checks the assertions of an object being
+// manifested. If one fails, the error says where the object is, so that it
+// can be found in a large output. Other errors are left as they are.
+func (i *interpreter) checkManifestedAssertions(e *evaluator, obj valueObject, path *manifestPath) error {
+	err := checkAssertions(e, obj)
+	if rte, ok := err.(RuntimeError); ok && rte.assertionFailed && path != nil {
+		rte.Msg = "assertion failed" + path.at() + ": " + rte.Msg
+		return rte
+	}
+	return err
+}
+
+// manifestOrderField is the name of the field which lists the fields of an
+// object in the order they are manifested in.
+const manifestOrderField = "__order__"
+
+// manifestedFields returns the visible fields of obj in the order in which
+// they are manifested. If obj has an __order__ field (usually hidden), it
+// must be an array of field names, and the fields listed there come first,
+// in that order. Names of fields which don't exist or are hidden are ignored.
+// The other fields follow sorted, or in definition order with preserveOrder.
+func manifestedFields(e *evaluator, obj valueObject, preserveOrder bool) ([]string, error) {
+	fieldNames := objectFieldsOrdered(obj, withoutHidden, preserveOrder)
+	orderp := tryObjectIndex(objectBinding(obj), manifestOrderField, withHidden)
+	if orderp == nil {
+		return fieldNames, nil
+	}
+	orderValue, err := e.evaluate(orderp)
+	if err != nil {
+		return nil, err
+	}
+	order, ok := orderValue.(*valueArray)
+	if !ok {
+		return nil, e.Error(fmt.Sprintf("%s should be an array of field names, got %s", manifestOrderField, orderValue.typename()))
+	}
+	remaining := make(map[string]bool, len(fieldNames))
+	for _, fieldName := range fieldNames {
+		remaining[fieldName] = true
+	}
+	ordered := make([]string, 0, len(fieldNames))
+	for index, elem := range order.elements {
+		nameValue, err := e.evaluate(elem)
+		if err != nil {
+			return nil, err
+		}
+		name, ok := nameValue.(*valueString)
+		if !ok {
+			return nil, e.Error(fmt.Sprintf("%s should be an array of field names, got %s at index %d", manifestOrderField, nameValue.typename(), index))
+		}
+		if remaining[name.getString()] {
+			ordered = append(ordered, name.getString())
+			delete(remaining, name.getString())
+		}
+	}
+	for _, fieldName := range fieldNames {
+		if remaining[fieldName] {
+			ordered = append(ordered, fieldName)
+		}
+	}
+	return ordered, nil
+}
+
+// manifestJSONOptions control the layout of manifested JSON.
+type manifestJSONOptions struct {
+	// Put each element and field on a separate line.
+	multiline bool
+	// Added for every level of nesting in multiline mode.
+	indent string
+	// In multiline mode, arrays which fit in that many characters when
+	// written on a single line are not expanded. 0 means always expand.
+	compactArrayWidth int
+	// Output object fields in the order they were defined instead of sorted.
+	preserveOrder bool
+	// Write empty arrays and objects as [] and {} rather than [ ] and { }.
+	tightEmpty bool
+	// Ends the lines in multiline mode. "\n" if empty.
+	newline string
+	// Write all numbers with decimalPlaces digits after the decimal point,
+	// e.g. 2.50 for 2.5 with two decimal places. Numbers are rounded by
+	// their exact binary value, so 1.005 becomes 1.00, and ties to even.
+	fixedDecimals bool
+	decimalPlaces int
+	// Called with the key and the value of every field and array element
+	// before it is written. Its result is written instead, or the value is
+	// omitted if it returns std.manifestOmit. nil if there is no replacer.
+	replacer *valueFunction
+	// The results of the replacer, so that it is called once per field and
+	// element even though arrays may be written twice, see
+	// manifestCompactArray. Must be set if replacer is.
+	replaced map[replacedKey]replacedValue
+	// In multiline mode, write a "// from <location>" comment before every
+	// field of the top-level object. The output is then not valid JSON.
+	annotateSources bool
+	// Escape all non-ASCII characters in strings, and override the escaping
+	// of the characters escapeRune accepts. Only set for the final output,
+	// so that the strings manifested by the program don't depend on the VM
+	// settings.
+	asciiOutput bool
+	escapeRune  func(rune) (string, bool)
+}
+
+func (opts *manifestJSONOptions) lineBreak() string {
+	if opts.newline == "" {
+		return "\n"
+	}
+	return opts.newline
+}
+
+var (
+	multilineJSON  = &manifestJSONOptions{multiline: true, indent: "   "}
+	singleLineJSON = &manifestJSONOptions{}
+)
+
+var errOutputSizeLimit = errors.New("output size limit exceeded")
+
+// manifestBuffer is the bytes.Buffer manifestJSON writes to. If max isn't 0,
+// it never holds more than max bytes: the writes which don't fit are dropped
+// and exceeded is set, so that the limit bounds the memory used while the
+// output is built, not only its final size.
+type manifestBuffer struct {
+	bytes.Buffer
+	max      int
+	exceeded bool
+}
+
+func (b *manifestBuffer) fits(n int) bool {
+	if b.max > 0 && b.Len()+n > b.max {
+		b.exceeded = true
+	}
+	return !b.exceeded
+}
+
+func (b *manifestBuffer) Write(p []byte) (int, error) {
+	if !b.fits(len(p)) {
+		return 0, errOutputSizeLimit
+	}
+	return b.Buffer.Write(p)
+}
+
+func (b *manifestBuffer) WriteString(s string) (int, error) {
+	if !b.fits(len(s)) {
+		return 0, errOutputSizeLimit
+	}
+	return b.Buffer.WriteString(s)
+}
+
+func (b *manifestBuffer) WriteRune(r rune) (int, error) {
+	n := utf8.RuneLen(r)
+	if n < 0 {
+		n = utf8.RuneLen(utf8.RuneError)
+	}
+	if !b.fits(n) {
+		return 0, errOutputSizeLimit
+	}
+	return b.Buffer.WriteRune(r)
+}
+
+func (b *manifestBuffer) WriteByte(c byte) error {
+	if !b.fits(1) {
+		return errOutputSizeLimit
+	}
+	return b.Buffer.WriteByte(c)
+}
+
+// manifestCompactArray writes arr on a single line if it fits in
+// opts.compactArrayWidth characters. It returns whether it did.
+func (i *interpreter) manifestCompactArray(trace *TraceElement, arr *valueArray, opts *manifestJSONOptions, path *manifestPath, buf *manifestBuffer) (bool, error) {
+	compactOpts := &manifestJSONOptions{
+		preserveOrder: opts.preserveOrder,
+		tightEmpty:    opts.tightEmpty,
+		fixedDecimals: opts.fixedDecimals,
+		decimalPlaces: opts.decimalPlaces,
+		replacer:      opts.replacer,
+		replaced:      opts.replaced,
+		asciiOutput:   opts.asciiOutput,
+		escapeRune:    opts.escapeRune,
+	}
+	// A character takes at most utf8.UTFMax bytes, so the array doesn't fit
+	// once that limit is exceeded. It keeps a single huge element from being
+	// written in full.
+	compact := manifestBuffer{max: utf8.UTFMax * (opts.compactArrayWidth + 2)}
+	compact.WriteString("[")
+	for index, th := range arr.elements {
+		elVal, err := th.getValue(i, trace)
+		if err != nil {
+			return false, err
+		}
+		elVal, err = i.replaceArrayElement(trace, opts, arr, index, elVal)
+		if err != nil {
+			return false, err
+		}
+		if index > 0 {
+			compact.WriteString(", ")
+		}
+		err = i.manifestJSON(trace, elVal, compactOpts, "", path.withIndex(index), &compact)
+		if compact.exceeded {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		// Give up early, so that large arrays are not written twice.
+		if utf8.RuneCount(compact.Bytes()) > opts.compactArrayWidth {
+			return false, nil
+		}
+	}
+	compact.WriteString("]")
+	if utf8.RuneCount(compact.Bytes()) > opts.compactArrayWidth {
+		return false, nil
+	}
+	buf.Write(compact.Bytes())
+	return true, nil
+}
+
+// replacedKey identifies a field of an object or an element of an array
+// passed through the replacer. The zero value is the top-level value.
+type replacedKey struct {
+	container value
+	field     string
+	index     int
+}
+
+// replacedValue is a result of the replacer.
+type replacedValue struct {
+	v       value
+	omitted bool
+}
+
+// replaceManifested passes a field or an array element through the replacer
+// of opts, if any. It reports whether the replacer asked to omit the value.
+func (i *interpreter) replaceManifested(trace *TraceElement, opts *manifestJSONOptions, at replacedKey, v value) (value, bool, error) {
+	if opts.replacer == nil {
+		return v, false, nil
+	}
+	if r, ok := opts.replaced[at]; ok {
+		return r.v, r.omitted, nil
+	}
+	var key value = makeValueString(at.field)
+	if _, ok := at.container.(*valueArray); ok {
+		key = intToValue(at.index)
+	}
+	e := &evaluator{i: i, trace: trace}
+	replaced, err := e.evaluate(opts.replacer.call(args(&readyValue{key}, &readyValue{v})))
+	if err != nil {
+		return nil, false, err
+	}
+	r := replacedValue{v: replaced, omitted: replaced == i.manifestOmit}
+	opts.replaced[at] = r
+	return r.v, r.omitted, nil
+}
+
+// replaceArrayElement is replaceManifested for array elements. Like in
+// JSON.stringify, omitted elements are written as null, so that the indices
+// of the others don't change.
+func (i *interpreter) replaceArrayElement(trace *TraceElement, opts *manifestJSONOptions, arr *valueArray, index int, v value) (value, error) {
+	replaced, omitted, err := i.replaceManifested(trace, opts, replacedKey{container: arr, index: index}, v)
+	if err != nil || !omitted {
+		return replaced, err
+	}
+	return makeValueNull(), nil
+}
+
 // TODO(sbarzowski) Perhaps it should be a builtin?
 // TODO(sbarzowski) Perhaps we should separate recursive evaluation from serialization?
 // 					Strictly evaluating something may be useful by itself.
-func (i *interpreter) manifestJSON(trace *TraceElement, v value, multiline bool, indent string, buf *bytes.Buffer) error {
+func (i *interpreter) manifestJSON(trace *TraceElement, v value, opts *manifestJSONOptions, indent string, path *manifestPath, buf *manifestBuffer) error {
 	// TODO(dcunnin): All the other types...
-	e := &evaluator{i: i, trace: trace}
+	if err := i.checkManifestDepth(trace, v, path); err != nil {
+		return err
+	}
+	multiline := opts.multiline
 	switch v := v.(type) {
 	case *valueArray:
 		if len(v.elements) == 0 {
-			buf.WriteString("[ ]")
+			if opts.tightEmpty {
+				buf.WriteString("[]")
+			} else {
+				buf.WriteString("[ ]")
+			}
 		} else {
-			var prefix string
-			var indent2 string
+			if multiline && opts.compactArrayWidth > 0 {
+				done, err := i.manifestCompactArray(trace, v, opts, path, buf)
+				if err != nil || done {
+					return err
+				}
+			}
+			var prefix, separator, indent2 string
 			if multiline {
-				prefix = "[\n"
-				indent2 = indent + "   "
+				prefix = "[" + opts.lineBreak()
+				separator = "," + opts.lineBreak()
+				indent2 = indent + opts.indent
 			} else {
 				prefix = "["
+				separator = ", "
 				indent2 = indent
 			}
-			for _, th := range v.elements {
+			for index, th := range v.elements {
 				// if th.body != nil {
 				// 	tloc = th.body.Loc()
 				// }
@@ -553,20 +1177,20 @@ func (i *interpreter) manifestJSON(trace *TraceElement, v value, multiline bool,
 				if err != nil {
 					return err
 				}
+				elVal, err = i.replaceArrayElement(trace, opts, v, index, elVal)
+				if err != nil {
+					return err
+				}
 				buf.WriteString(prefix)
 				buf.WriteString(indent2)
-				err = i.manifestJSON(trace, elVal, multiline, indent2, buf)
+				err = i.manifestJSON(trace, elVal, opts, indent2, path.withIndex(index), buf)
 				if err != nil {
 					return err
 				}
-				if multiline {
-					prefix = ",\n"
-				} else {
-					prefix = ", "
-				}
+				prefix = separator
 			}
 			if multiline {
-				buf.WriteString("\n")
+				buf.WriteString(opts.lineBreak())
 			}
 			buf.WriteString(indent)
 			buf.WriteString("]")
@@ -580,69 +1204,93 @@ func (i *interpreter) manifestJSON(trace *TraceElement, v value, multiline bool,
 		}
 
 	case *valueFunction:
-		return makeRuntimeError("Couldn't manifest function in JSON output.", i.getCurrentStackTrace(trace))
+		return i.manifestFunctionError(trace, "JSON", path)
 
 	case *valueNumber:
-		buf.WriteString(unparseNumber(v.value))
+		if opts.fixedDecimals {
+			buf.WriteString(strconv.FormatFloat(v.value, 'f', opts.decimalPlaces, 64))
+		} else {
+			buf.WriteString(i.unparseOutputNumber(v.value))
+		}
 
 	case *valueNull:
 		buf.WriteString("null")
 
 	case valueObject:
-		fieldNames := objectFields(v, withoutHidden)
-		sort.Strings(fieldNames)
-
-		err := checkAssertions(e, v)
+		e := &evaluator{i: i, trace: trace}
+		err := i.checkManifestedAssertions(e, v, path)
+		if err != nil {
+			return err
+		}
+		fieldNames, err := manifestedFields(e, v, opts.preserveOrder)
 		if err != nil {
 			return err
 		}
 
-		if len(fieldNames) == 0 {
-			buf.WriteString("{ }")
+		var prefix, separator, indent2 string
+		if multiline {
+			prefix = "{" + opts.lineBreak()
+			separator = "," + opts.lineBreak()
+			indent2 = indent + opts.indent
 		} else {
-			var prefix string
-			var indent2 string
-			if multiline {
-				prefix = "{\n"
-				indent2 = indent + "   "
-			} else {
-				prefix = "{"
-				indent2 = indent
+			prefix = "{"
+			separator = ", "
+			indent2 = indent
+		}
+		// Nothing is written until the first field which isn't omitted.
+		empty := true
+		for _, fieldName := range fieldNames {
+			fieldVal, err := v.index(e, fieldName)
+			if err != nil {
+				return err
 			}
-			for _, fieldName := range fieldNames {
-				fieldVal, err := v.index(e, fieldName)
-				if err != nil {
-					return err
+			fieldVal, omitted, err := i.replaceManifested(trace, opts, replacedKey{container: v, field: fieldName}, fieldVal)
+			if err != nil {
+				return err
+			}
+			if omitted {
+				continue
+			}
+			empty = false
+
+			buf.WriteString(prefix)
+			if opts.annotateSources && multiline && path == nil {
+				if loc := objectFieldLocation(v, fieldName); loc != nil {
+					buf.WriteString(indent2)
+					buf.WriteString("// from " + loc.String())
+					buf.WriteString(opts.lineBreak())
 				}
+			}
+			buf.WriteString(indent2)
 
-				buf.WriteString(prefix)
-				buf.WriteString(indent2)
+			writeUnparsedString(buf, fieldName, opts.asciiOutput, opts.escapeRune)
+			buf.WriteString(": ")
 
-				buf.WriteString(unparseString(fieldName))
-				buf.WriteString(": ")
+			// TODO(sbarzowski) body.Loc()
+			err = i.manifestJSON(trace, fieldVal, opts, indent2, path.withField(fieldName), buf)
+			if err != nil {
+				return err
+			}
 
-				// TODO(sbarzowski) body.Loc()
-				err = i.manifestJSON(trace, fieldVal, multiline, indent2, buf)
-				if err != nil {
-					return err
-				}
+			prefix = separator
+		}
 
-				if multiline {
-					prefix = ",\n"
-				} else {
-					prefix = ", "
-				}
+		if empty {
+			if opts.tightEmpty {
+				buf.WriteString("{}")
+			} else {
+				buf.WriteString("{ }")
 			}
-
+		} else {
 			if multiline {
-				buf.WriteString("\n")
+				buf.WriteString(opts.lineBreak())
 			}
 			buf.WriteString(indent)
 			buf.WriteString("}")
 		}
 
 	case *valueString:
-		buf.WriteString(unparseString(v.getString()))
+		writeUnparsedValueString(buf, v, opts.asciiOutput, opts.escapeRune)
 
 	default:
 		return makeRuntimeError(
@@ -651,17 +1299,353 @@ func (i *interpreter) manifestJSON(trace *TraceElement, v value, multiline bool,
 		)
 
 	}
+	if buf.exceeded {
+		e := &evaluator{i: i, trace: trace}
+		return e.Error(errOutputSizeLimit.Error())
+	}
 	return nil
 }
 
+// yamlContext describes where a value is placed in a YAML document, which
+// determines how its first line and nested lines are laid out.
+type yamlContext int
+
+const (
+	yamlTopLevel yamlContext = iota
+	yamlInArray
+	yamlInObject
+)
+
+// yamlBlockScalar checks if a string can be represented faithfully as a YAML
+// literal block scalar. If so, it returns the chomping indicator and the lines
+// of the block. Strings without newlines and strings which would be mangled
+// by the block style (e.g. trailing whitespace, control characters, leading
+// whitespace which would be taken for indentation) are not block-safe.
+func yamlBlockScalar(s string) (chomp string, lines []string, ok bool) {
+	if !strings.Contains(s, "\n") {
+		return "", nil, false
+	}
+	if strings.HasSuffix(s, "\n\n") {
+		// Would require "keep" chomping, which relies on the text that follows.
+		return "", nil, false
+	}
+	for _, c := range s {
+		if c != '\n' && c != '\t' && (c < 0x20 || (c >= 0x7f && c <= 0x9f)) {
+			return "", nil, false
+		}
+	}
+	if strings.HasSuffix(s, "\n") {
+		lines = strings.Split(s[:len(s)-1], "\n")
+	} else {
+		chomp = "-"
+		lines = strings.Split(s, "\n")
+	}
+	firstContentLine := true
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		if firstContentLine && (line[0] == ' ' || line[0] == '\t') {
+			return "", nil, false
+		}
+		firstContentLine = false
+		if last := line[len(line)-1]; last == ' ' || last == '\t' {
+			return "", nil, false
+		}
+	}
+	if firstContentLine {
+		// Only empty lines.
+		return "", nil, false
+	}
+	return chomp, lines, true
+}
+
+// yamlNumber formats a number as a YAML scalar. All numbers are float64, so
+// the rule is the same as for JSON: numbers without a fractional part are
+// written as integers (1, not 1.0) and the others in their usual form (1.5).
+// Exponents are only used for fractions of very small magnitude, and YAML 1.1
+// only reads those as floats when the mantissa has a decimal point, so 1e-07
+// is written as 1.0e-07.
+func (i *interpreter) yamlNumber(v float64) string {
+	s := i.unparseOutputNumber(v)
+	if e := strings.IndexByte(s, 'e'); e >= 0 && !strings.Contains(s[:e], ".") {
+		s = s[:e] + ".0" + s[e:]
+	}
+	return s
+}
+
+// manifestYAMLOptions control the YAML output.
+type manifestYAMLOptions struct {
+	// Output mapping keys in the order they were defined instead of sorted.
+	preserveOrder bool
+	// Indent the items of an array which is the value of a field under its
+	// key, rather than aligning the dashes with the key:
+	//
+	//	a:          a:
+	//	  - 1       - 1
+	indentArrayInObject bool
+}
+
+// manifestYAML writes v to buf as YAML.
+// cindent is the indentation of the line on which v starts.
+// Like upstream, null, empty arrays and empty objects are written inline as
+// null, [] and {}, both nested and as the whole document. Values used in
+// several places are written out in full every time, there are no anchors
+// and aliases, so every document is self-contained.
+func (i *interpreter) manifestYAML(trace *TraceElement, v value, context yamlContext, cindent string, opts *manifestYAMLOptions, path *manifestPath, buf *bytes.Buffer) error {
+	e := &evaluator{i: i, trace: trace}
+	if err := i.checkManifestDepth(trace, v, path); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case *valueArray:
+		if len(v.elements) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		var dashIndent string
+		switch context {
+		case yamlTopLevel:
+			dashIndent = cindent
+		case yamlInObject:
+			dashIndent = cindent
+			if opts.indentArrayInObject {
+				dashIndent = cindent + "  "
+			}
+		case yamlInArray:
+			dashIndent = cindent + "  "
+		}
+		for index, th := range v.elements {
+			elVal, err := th.getValue(i, trace)
+			if err != nil {
+				return err
+			}
+			if index > 0 || context == yamlInObject {
+				buf.WriteString("\n")
+				buf.WriteString(dashIndent)
+			}
+			buf.WriteString("- ")
+			err = i.manifestYAML(trace, elVal, yamlInArray, dashIndent, opts, path.withIndex(index), buf)
+			if err != nil {
+				return err
+			}
+		}
+
+	case *valueFunction:
+		return i.manifestFunctionError(trace, "YAML", path)
+
+	case valueObject:
+		err := i.checkManifestedAssertions(e, v, path)
+		if err != nil {
+			return err
+		}
+		fieldNames, err := manifestedFields(e, v, opts.preserveOrder)
+		if err != nil {
+			return err
+		}
+
+		if len(fieldNames) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		keyIndent := cindent
+		if context != yamlTopLevel {
+			keyIndent = cindent + "  "
+		}
+		for index, fieldName := range fieldNames {
+			fieldVal, err := v.index(e, fieldName)
+			if err != nil {
+				return err
+			}
+			if index > 0 || context == yamlInObject {
+				buf.WriteString("\n")
+				buf.WriteString(keyIndent)
+			}
+			// Keys are always quoted, so that e.g. "yes" or "123" is not
+			// read back as a boolean or a number.
+			writeUnparsedString(buf, fieldName, false, nil)
+			buf.WriteString(":")
+			switch fieldVal := fieldVal.(type) {
+			case *valueArray:
+				if len(fieldVal.elements) == 0 {
+					buf.WriteString(" ")
+				}
+			case valueObject:
+				if len(objectFields(fieldVal, withoutHidden)) == 0 {
+					buf.WriteString(" ")
+				}
+			default:
+				buf.WriteString(" ")
+			}
+			err = i.manifestYAML(trace, fieldVal, yamlInObject, keyIndent, opts, path.withField(fieldName), buf)
+			if err != nil {
+				return err
+			}
+		}
+
+	case *valueString:
+		chomp, lines, ok := yamlBlockScalar(v.getString())
+		if !ok {
+			writeUnparsedValueString(buf, v, false, nil)
+			return nil
+		}
+		buf.WriteString("|")
+		buf.WriteString(chomp)
+		for _, line := range lines {
+			buf.WriteString("\n")
+			if line != "" {
+				buf.WriteString(cindent)
+				buf.WriteString("  ")
+				buf.WriteString(line)
+			}
+		}
+
+	case *valueNumber:
+		buf.WriteString(i.yamlNumber(v.value))
+
+	default:
+		// Other scalars look the same as in JSON.
+		var scalar manifestBuffer
+		if err := i.manifestJSON(trace, v, singleLineJSON, "", path, &scalar); err != nil {
+			return err
+		}
+		buf.Write(scalar.Bytes())
+	}
+	return nil
+}
+
+// isXMLName reports whether name can be used as an XML tag or attribute name.
+// Colons are allowed, so namespaced names like svg:rect are passed through.
+func isXMLName(name string) bool {
+	for index, r := range name {
+		isStart := r == '_' || r == ':' || unicode.IsLetter(r)
+		if !isStart && (index == 0 || (r != '-' && r != '.' && !unicode.IsDigit(r))) {
+			return false
+		}
+	}
+	return name != ""
+}
+
+var (
+	xmlTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	// Attribute values are always quoted with ", so that is escaped too.
+	// Whitespace other than spaces would be normalized away by XML parsers.
+	xmlAttributeEscaper = strings.NewReplacer(
+		"&", "&amp;", "<", "&lt;", ">", "&gt;", "\"", "&quot;",
+		"\n", "&#10;", "\r", "&#13;", "\t", "&#9;")
+)
+
+// manifestXMLJsonml writes a JsonML element, i.e. an array of the form
+// [tag, attributes?, children...], to buf as XML. Children are either strings,
+// which are escaped, or elements. Elements without children are self-closing.
+func (i *interpreter) manifestXMLJsonml(trace *TraceElement, v value, path *manifestPath, buf *bytes.Buffer) error {
+	e := &evaluator{i: i, trace: trace}
+	if err := i.checkManifestDepth(trace, v, path); err != nil {
+		return err
+	}
+	errorAt := func(msg string) error {
+		return e.Error(msg + path.at())
+	}
+	if text, ok := v.(*valueString); ok {
+		buf.WriteString(xmlTextEscaper.Replace(text.getString()))
+		return nil
+	}
+	arr, ok := v.(*valueArray)
+	if !ok {
+		return errorAt(fmt.Sprintf("JsonML element must be an array or a string, got %s", v.typename()))
+	}
+	if len(arr.elements) == 0 {
+		return errorAt("JsonML element must start with a tag name, got an empty array")
+	}
+	tagVal, err := arr.elements[0].getValue(i, trace)
+	if err != nil {
+		return err
+	}
+	tag, ok := tagVal.(*valueString)
+	if !ok {
+		return errorAt(fmt.Sprintf("JsonML tag name must be a string, got %s", tagVal.typename()))
+	}
+	if !isXMLName(tag.getString()) {
+		return errorAt(fmt.Sprintf("JsonML tag name must be a valid XML name, got %v", unparseString(tag.getString())))
+	}
+	buf.WriteString("<")
+	buf.WriteString(tag.getString())
+
+	children := arr.elements[1:]
+	childOffset := 1
+	if len(children) > 0 {
+		first, err := children[0].getValue(i, trace)
+		if err != nil {
+			return err
+		}
+		if attrs, ok := first.(valueObject); ok {
+			children = children[1:]
+			childOffset++
+			if err := checkAssertions(e, attrs); err != nil {
+				return err
+			}
+			for _, name := range objectFieldsSorted(attrs, withoutHidden) {
+				if !isXMLName(name) {
+					return errorAt(fmt.Sprintf("JsonML attribute name must be a valid XML name, got %v", unparseString(name)))
+				}
+				attrVal, err := attrs.index(e, name)
+				if err != nil {
+					return err
+				}
+				var text string
+				switch attrVal := attrVal.(type) {
+				case *valueString:
+					text = attrVal.getString()
+				case *valueNumber:
+					text = i.unparseOutputNumber(attrVal.value)
+				case *valueBoolean:
+					text = strconv.FormatBool(attrVal.value)
+				default:
+					return errorAt(fmt.Sprintf("JsonML attribute %v must be a string, number or boolean, got %s",
+						unparseString(name), attrVal.typename()))
+				}
+				buf.WriteString(" ")
+				buf.WriteString(name)
+				buf.WriteString("=\"")
+				buf.WriteString(xmlAttributeEscaper.Replace(text))
+				buf.WriteString("\"")
+			}
+		}
+	}
+
+	if len(children) == 0 {
+		buf.WriteString("/>")
+		return nil
+	}
+	buf.WriteString(">")
+	for index, th := range children {
+		child, err := th.getValue(i, trace)
+		if err != nil {
+			return err
+		}
+		err = i.manifestXMLJsonml(trace, child, path.withIndex(index+childOffset), buf)
+		if err != nil {
+			return err
+		}
+	}
+	buf.WriteString("</")
+	buf.WriteString(tag.getString())
+	buf.WriteString(">")
+	return nil
+}
+
+// EvalInCleanEnv evaluates ast in a new call frame with the given environment.
+// If trimmable is set, the frame may be dropped by a call in tail position,
+// see tailCallTrimStack.
 func (i *interpreter) EvalInCleanEnv(fromWhere *TraceElement, newContext *TraceContext,
-	env *environment, ast ast.Node) (value, error) {
-	err := i.newCall(fromWhere, *env)
+	env *environment, ast ast.Node, trimmable bool) (value, error) {
+	err := i.newCall(fromWhere, *env, trimmable)
 	if err != nil {
 		return nil, err
 	}
-	val, err := i.evaluate(ast, newContext)
-	i.stack.pop()
+	stackSize := len(i.stack.stack)
+	val, err := i.evaluate(ast, newContext, tailCall)
+	i.stack.popIfExists(stackSize)
 	return val, err
 }
 
@@ -676,13 +1660,42 @@ func buildStdObject(i *interpreter) (value, error) {
 		function := valueFunction{ec: ec} // TODO(sbarzowski) better way to build function value
 		builtinFields[key] = &readyValue{&function}
 	}
+	i.manifestOmit = makeValueSimpleObject(bindingFrame{}, valueSimpleObjectFieldMap{}, nil)
+	builtinFields["manifestOmit"] = &readyValue{i.manifestOmit}
 
 	for name, value := range builtinFields {
-		obj.fields[name] = valueSimpleObjectField{ast.ObjectFieldHidden, value}
+		obj.fields[name] = valueSimpleObjectField{ast.ObjectFieldHidden, value, len(obj.fields), false}
 	}
 	return obj, nil
 }
 
+// stdFileName is the file name of the standard library in locations.
+const stdFileName = "std.jsonnet"
+
+var (
+	stdAST    ast.Node
+	stdASTErr error
+	// stdLocations holds the locations of the nodes of std.jsonnet, so that
+	// its code can be told apart from user code, e.g. by builtins to know
+	// whether the standard library called them.
+	stdLocations map[*ast.LocationRange]bool
+	stdASTOnce   sync.Once
+)
+
+// getStdAST parses std.jsonnet the first time it is needed. Evaluation
+// doesn't modify the AST, so it is shared by all VMs.
+func getStdAST() (ast.Node, error) {
+	stdASTOnce.Do(func() {
+		stdAST, stdASTErr = desugaredSnippet(stdFileName, getStdCode())
+		if stdASTErr != nil {
+			return
+		}
+		stdLocations = make(map[*ast.LocationRange]bool)
+		stdASTErr = analyzeWithOptions(stdAST, &analysisOptions{locations: stdLocations})
+	})
+	return stdAST, stdASTErr
+}
+
 func evaluateStd(i *interpreter) (value, error) {
 	beforeStdEnv := makeEnvironment(
 		bindingFrame{},
@@ -690,38 +1703,48 @@ func evaluateStd(i *interpreter) (value, error) {
 	)
 	evalLoc := ast.MakeLocationRangeMessage("During evaluation of std")
 	evalTrace := &TraceElement{loc: &evalLoc}
-	node, err := snippetToAST("std.jsonnet", getStdCode())
+	node, err := getStdAST()
 	if err != nil {
 		return nil, err
 	}
 	context := TraceContext{Name: "<stdlib>"}
-	return i.EvalInCleanEnv(evalTrace, &context, &beforeStdEnv, node)
+	return i.EvalInCleanEnv(evalTrace, &context, &beforeStdEnv, node, false)
 }
 
-func prepareExtVars(i *interpreter, ext vmExtMap) map[ast.Identifier]potentialValue {
+func prepareExtVars(i *interpreter, ext vmExtMap) (map[ast.Identifier]potentialValue, error) {
 	result := make(map[ast.Identifier]potentialValue)
+	varLoc := ast.MakeLocationRangeMessage("During evaluation")
+	varTrace := &TraceElement{
+		loc: &varLoc,
+	}
+	e := &evaluator{
+		i:     i,
+		trace: varTrace,
+	}
 	for name, content := range ext {
 		if content.isCode {
-			varLoc := ast.MakeLocationRangeMessage("During evaluation")
-			varTrace := &TraceElement{
-				loc: &varLoc,
-			}
-			e := &evaluator{
-				i:     i,
-				trace: varTrace,
-			}
 			result[ast.Identifier(name)] = codeToPV(e, "<extvar:"+name+">", content.value)
+		} else if content.isData {
+			// ExtData validated the data, but it may have been modified since.
+			v, err := valueFromGo(content.data)
+			if err != nil {
+				return nil, e.Error(fmt.Sprintf("External variable %s: %v", name, err))
+			}
+			result[ast.Identifier(name)] = &readyValue{v}
 		} else {
 			result[ast.Identifier(name)] = &readyValue{makeValueString(content.value)}
 		}
 	}
-	return result
+	return result, nil
 }
 
-func buildInterpreter(ext vmExtMap, maxStack int, importer Importer) (*interpreter, error) {
+func buildInterpreter(ext vmExtMap, maxStack int, evalOpts evaluationOptions, manifestOpts manifestationOptions, importer Importer) (*interpreter, error) {
 	i := interpreter{
-		stack:       makeCallStack(maxStack),
-		importCache: MakeImportCache(importer),
+		stack:        makeCallStack(maxStack),
+		importCache:  MakeImportCache(importer),
+		evalOpts:     evalOpts,
+		manifestOpts: manifestOpts,
+		builtinCache: make(map[builtinCacheKey]value),
 	}
 
 	stdObj, err := buildStdObject(&i)
@@ -736,22 +1759,35 @@ func buildInterpreter(ext vmExtMap, maxStack int, importer Importer) (*interpret
 		makeUnboundSelfBinding(),
 	)
 
-	i.extVars = prepareExtVars(&i, ext)
+	i.extVars, err = prepareExtVars(&i, ext)
+	if err != nil {
+		return nil, err
+	}
 
 	return &i, nil
 }
 
 func manifest(e *evaluator, v value) (string, error) {
-	var buffer bytes.Buffer
-	err := e.i.manifestJSON(e.trace, v, true, "", &buffer)
+	buffer := manifestBuffer{max: e.i.manifestOpts.maxOutputSize}
+	opts := *multilineJSON
+	if e.i.manifestOpts.crlf {
+		opts.newline = "\r\n"
+	}
+	opts.annotateSources = e.i.manifestOpts.annotateSources
+	opts.asciiOutput = e.i.manifestOpts.asciiOutput
+	opts.escapeRune = e.i.manifestOpts.escapeRune
+	if e.i.manifestOpts.bom {
+		buffer.WriteString("\uFEFF")
+	}
+	err := e.i.manifestJSON(e.trace, v, &opts, "", nil, &buffer)
 	if err != nil {
 		return "", err
 	}
 	return buffer.String(), nil
 }
 
-func evaluate(node ast.Node, ext vmExtMap, maxStack int, importer Importer) (string, error) {
-	i, err := buildInterpreter(ext, maxStack, importer)
+func evaluate(node ast.Node, ext vmExtMap, maxStack int, evalOpts evaluationOptions, manifestOpts manifestationOptions, importer Importer) (string, error) {
+	i, err := buildInterpreter(ext, maxStack, evalOpts, manifestOpts, importer)
 	if err != nil {
 		return "", err
 	}
@@ -760,7 +1796,7 @@ func evaluate(node ast.Node, ext vmExtMap, maxStack int, importer Importer) (str
 		loc: &evalLoc,
 	}
 	context := TraceContext{Name: "<main>"}
-	result, err := i.EvalInCleanEnv(evalTrace, &context, &i.initialEnv, node)
+	result, err := i.EvalInCleanEnv(evalTrace, &context, &i.initialEnv, node, false)
 	if err != nil {
 		return "", err
 	}
@@ -768,6 +1804,12 @@ func evaluate(node ast.Node, ext vmExtMap, maxStack int, importer Importer) (str
 	manifestationTrace := &TraceElement{
 		loc: &manifestationLoc,
 	}
+	if _, ok := result.(*valueFunction); ok {
+		// Most likely the program expects top-level arguments, which is a
+		// common mistake, so a generic manifestation error would not help.
+		return "", makeRuntimeError("top-level result is a function; did you mean to supply top-level arguments?",
+			i.getCurrentStackTrace(manifestationTrace))
+	}
 	e := &evaluator{
 		i:     i,
 		trace: manifestationTrace,
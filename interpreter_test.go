@@ -15,3 +15,104 @@ limitations under the License.
 */
 
 package jsonnet
+
+import (
+	"testing"
+
+	"github.com/google/go-jsonnet/ast"
+)
+
+// Representative programs for BenchmarkEvaluate. They stress thunk creation,
+// function calls and object lookups rather than the standard library.
+var benchmarkPrograms = []struct {
+	name    string
+	snippet string
+}{
+	{"ArrayComprehension", `std.length([x * y for x in std.range(1, 300) for y in std.range(1, 30) if (x + y) % 3 != 0])`},
+	{"DeepInheritance", `
+		local layer(i) = { ["f" + i]: i, depth: super.depth + 1, sum: super.sum + self.depth };
+		local obj = std.foldl(function(acc, i) acc + layer(i), std.range(1, 100), { depth: 0, sum: 0 });
+		[obj.depth, obj.sum, obj.f50]`},
+	{"FieldAccess", `
+		local obj = { data: std.range(1, 1000), total: std.foldl(function(acc, x) acc + x, self.data, 0) };
+		std.foldl(function(acc, i) acc + obj.total, std.range(1, 1000), 0)`},
+	{"Foldl", `std.foldl(function(acc, x) acc + x * 2, std.range(1, 20000), 0)`},
+	{"Recursion", `local fib(n) = if n < 2 then n else fib(n - 1) + fib(n - 2); fib(17)`},
+	{"Md5", `std.foldl(function(acc, i) acc + std.length(std.md5("The quick brown fox jumps over the lazy dog")), std.range(1, 5000), 0)`},
+	{"Manifestation", `{ ["k" + i]: { id: i, tags: ["a", "b", "c"], nested: { v: i * 1.5 } } for i in std.range(1, 2000) }`},
+}
+
+func BenchmarkEvaluate(b *testing.B) {
+	for _, program := range benchmarkPrograms {
+		b.Run(program.name, func(b *testing.B) {
+			vm := MakeVM()
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				if _, err := vm.evaluateSnippet(program.name, program.snippet); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkEvaluateTrivial creates a new VM for every evaluation, so it
+// measures the fixed cost of an evaluation, including setting up the
+// standard library.
+func BenchmarkEvaluateTrivial(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		vm := MakeVM()
+		if _, err := vm.evaluateSnippet("trivial", "{ a: 1 }"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkManifestLargeArray measures only the manifestation of a large
+// array. The array is evaluated once upfront and its elements are cached, so
+// the time per operation should grow linearly with the number of elements.
+func BenchmarkManifestLargeArray(b *testing.B) {
+	vm := MakeVM()
+	node, err := vm.parseSnippet("large", `std.makeArray(100000, function(i) if i % 2 == 0 then i else "item" + i)`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	manifestOpts := manifestationOptions{maxDepth: vm.MaxManifestDepth}
+	i, err := buildInterpreter(vm.ext, vm.MaxStack, evaluationOptions{}, manifestOpts, vm.importer)
+	if err != nil {
+		b.Fatal(err)
+	}
+	loc := ast.MakeLocationRangeMessage("benchmark")
+	e := &evaluator{i: i, trace: &TraceElement{loc: &loc}}
+	v, err := i.EvalInCleanEnv(e.trace, &TraceContext{Name: "<main>"}, &i.initialEnv, node, false)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if _, err := manifest(e, v); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if _, err := manifest(e, v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestManifestBufferLimit(t *testing.T) {
+	buf := manifestBuffer{max: 10}
+	buf.WriteString("[")
+	writeUnparsedString(&buf, "a string much longer than the limit", false, nil)
+	buf.WriteString("]")
+	if !buf.exceeded {
+		t.Errorf("expected the limit to be exceeded")
+	}
+	if buf.Len() > buf.max {
+		t.Errorf("expected at most %d bytes, got %d: %q", buf.max, buf.Len(), buf.String())
+	}
+	if got := buf.String(); got != `["a string` {
+		t.Errorf("expected the writes which fit before the limit, got %q", got)
+	}
+}
@@ -18,13 +18,23 @@ package jsonnet
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
 	"io/ioutil"
+	"math"
+	"math/rand"
+	"net/url"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"unicode/utf8"
 
+	"github.com/google/go-jsonnet/ast"
 	"github.com/sergi/go-diff/diffmatchpatch"
 )
 
@@ -137,6 +147,82 @@ func TestMain(t *testing.T) {
 	}
 }
 
+// TestManifestGolden checks the output of the manifestation builtins.
+// Each testdata/manifest/*.jsonnet evaluates to a string, which is compared
+// with the .golden file as is, so that the goldens are easy to read.
+// Run go test with -update to regenerate them.
+func TestManifestGolden(t *testing.T) {
+	match, err := filepath.Glob("testdata/manifest/*.jsonnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(match) == 0 {
+		t.Fatal("no manifestation tests found")
+	}
+	for _, input := range match {
+		name := strings.TrimSuffix(input, ".jsonnet")
+		t.Run(name, func(t *testing.T) {
+			code, err := ioutil.ReadFile(input)
+			if err != nil {
+				t.Fatalf("reading file: %s: %v", input, err)
+			}
+			vm := MakeVM()
+			output, err := vm.evaluateSnippet(input, string(code))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var manifested string
+			if err := json.Unmarshal([]byte(output), &manifested); err != nil {
+				t.Fatalf("%s must evaluate to a string, got %s", input, output)
+			}
+			golden := name + ".golden"
+			if *update {
+				if err := ioutil.WriteFile(golden, []byte(manifested), 0666); err != nil {
+					t.Errorf("error updating golden files: %v", err)
+				}
+				return
+			}
+			expected, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("reading file: %s: %v", golden, err)
+			}
+			if string(expected) != manifested {
+				t.Errorf("Mismatch when running %s. Golden: %s\n", input, golden)
+				t.Errorf("diff:\n%s", diff(manifested, string(expected)))
+			}
+		})
+	}
+}
+
+// TestManifestYamlRoundTrip parses the output of std.manifestYamlDoc back
+// with std.parseYaml and checks that it is the same value, to catch quoting
+// and escaping bugs in either direction.
+func TestManifestYamlRoundTrip(t *testing.T) {
+	values := []string{
+		`null`,
+		`"yes"`,
+		`["true", "false", "null", "~", "1", "1.5", "0x10", "1e3", "-", "- a", "a: b", "#", ""]`,
+		`{ "yes": "no", "123": "on", "null": "off", "": "", "a: b": "c # d" }`,
+		`[1, -2.5, 1e-7, 1e21, true, false, null]`,
+		`["a\nb", "a\nb\n", "a\n\nb\n\n", "\n", " leading", "trailing ", "tab\there", "\u0000\u001f", "ünïcødé ☃"]`,
+		`{ a: [], b: {}, c: [[]], d: [{}], e: { f: [] } }`,
+		`[[1, [2, [3]]], { a: { b: { c: [{ d: "e" }] } } }]`,
+		`{ "multi line key": 1, "quote\"key": 2, "'": 3 }`,
+	}
+	for _, value := range values {
+		vm := MakeVM()
+		snippet := "local v = " + value + "; local yaml = std.manifestYamlDoc(v); if std.parseYaml(yaml) == v then true else yaml"
+		output, err := vm.evaluateSnippet("round_trip", snippet)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", value, err)
+			continue
+		}
+		if output != "true" {
+			t.Errorf("%s: YAML %s doesn't parse back to the same value", value, output)
+		}
+	}
+}
+
 func diff(a, b string) string {
 	dmp := diffmatchpatch.New()
 	diffs := dmp.DiffMain(a, b, false)
@@ -210,3 +296,1241 @@ func TestMinimalError(t *testing.T) {
 		return formatter.format(r)
 	})
 }
+
+func TestImportRelativeToImportingFile(t *testing.T) {
+	// The main file is in a nested directory and we evaluate it from the
+	// repository root. Its imports must still be resolved relative to it.
+	filename := "testdata/nested_import/a/main.jsonnet"
+	input, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("reading file: %s: %v", filename, err)
+	}
+	vm := MakeVM()
+	output, err := vm.evaluateSnippet(filename, string(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := removeExcessiveWhitespace(
+		`{ "lib": { "name": "a/lib" }, "util": { "data": "a/sub/data.txt", "parent": "a/lib" } }`)
+	if removeExcessiveWhitespace(output) != expected {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", output, expected)
+	}
+}
+
+func TestMaxManifestDepth(t *testing.T) {
+	tests := []struct {
+		snippet  string
+		maxDepth int
+		errMsg   string
+	}{
+		{`{a: {b: [1]}}`, 3, ""},
+		{`{a: {b: [[]]}}`, 3, "RUNTIME ERROR: manifestation exceeded maximum depth"},
+		{`std.manifestYamlDoc({a: {b: [[]]}})`, 3, "RUNTIME ERROR: manifestation exceeded maximum depth"},
+		{`1`, 0, "MaxManifestDepth must be at least 1, got 0"},
+	}
+	for _, test := range tests {
+		vm := MakeVM()
+		vm.MaxManifestDepth = test.maxDepth
+		_, err := vm.evaluateSnippet("max_manifest_depth", test.snippet)
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		if errMsg != test.errMsg {
+			t.Errorf("%s with MaxManifestDepth = %d: expected error %#v, got %#v",
+				test.snippet, test.maxDepth, test.errMsg, errMsg)
+		}
+	}
+}
+
+func TestMaxOutputSize(t *testing.T) {
+	tests := []struct {
+		snippet string
+		maxSize int
+		errMsg  string
+	}{
+		{`{a: "xxxx"}`, 100, ""},
+		{`{a: "xxxx"}`, 0, ""},
+		{`std.join("", std.makeArray(2000, function(i) "xxxxxxxxxx"))`, 1000, "RUNTIME ERROR: output size limit exceeded"},
+		{`std.makeArray(2000, function(i) i)`, 1000, "RUNTIME ERROR: output size limit exceeded"},
+	}
+	for _, test := range tests {
+		vm := MakeVM()
+		vm.MaxOutputSize = test.maxSize
+		_, err := vm.evaluateSnippet("max_output_size", test.snippet)
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		if errMsg != test.errMsg {
+			t.Errorf("%s with MaxOutputSize = %d: expected error %#v, got %#v",
+				test.snippet, test.maxSize, test.errMsg, errMsg)
+		}
+	}
+}
+
+func TestMaxRangeLength(t *testing.T) {
+	tests := []struct {
+		snippet   string
+		maxLength int
+		errMsg    string
+	}{
+		{`std.length(std.range(1, 1000))`, 1000, ""},
+		{`std.length(std.range(1, 1000))`, 0, ""},
+		{`std.range(0, 1000)`, 1000, "RUNTIME ERROR: std.range would produce too many elements (more than 1000)"},
+		{`std.range(1, 2e9)`, 1000, "RUNTIME ERROR: std.range would produce too many elements (more than 1000)"},
+		{`std.reverseRange(1000, 0)`, 1000, "RUNTIME ERROR: std.reverseRange would produce too many elements (more than 1000)"},
+	}
+	for _, test := range tests {
+		vm := MakeVM()
+		vm.MaxRangeLength = test.maxLength
+		_, err := vm.evaluateSnippet("max_range_length", test.snippet)
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		if errMsg != test.errMsg {
+			t.Errorf("%s with MaxRangeLength = %d: expected error %#v, got %#v",
+				test.snippet, test.maxLength, test.errMsg, errMsg)
+		}
+	}
+}
+
+func TestExtData(t *testing.T) {
+	vm := MakeVM()
+	err := vm.ExtData("config", map[string]interface{}{
+		"name":     "web",
+		"replicas": 3,
+		"ports":    []interface{}{80.0, 443.0},
+		"tls":      true,
+		"extra":    nil,
+		"labels":   map[string]interface{}{"tier": "frontend"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	output, err := vm.evaluateSnippet("ext_data", `
+		local config = std.extVar("config");
+		{
+			name: config.name + "-1",
+			replicas: config.replicas * 2,
+			ports: config.ports,
+			tls: config.tls,
+			extra: config.extra,
+			tier: config.labels.tier,
+			fields: std.objectFields(config),
+		}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := `{ "extra": null, "fields": [ "extra", "labels", "name", "ports", "replicas", "tls" ], ` +
+		`"name": "web-1", "ports": [ 80, 443 ], "replicas": 6, "tier": "frontend", "tls": true }`
+	if removeExcessiveWhitespace(output) != expected {
+		t.Errorf("Expected %v, got %v", expected, removeExcessiveWhitespace(output))
+	}
+}
+
+func TestExtDataUnsupportedType(t *testing.T) {
+	vm := MakeVM()
+	err := vm.ExtData("x", map[string]interface{}{"a": []int{1, 2}})
+	if err == nil || err.Error() != "cannot convert value of type []int to a Jsonnet value" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestExtDataModified(t *testing.T) {
+	vm := MakeVM()
+	data := map[string]interface{}{"a": 1}
+	if err := vm.ExtData("x", data); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data["a"] = []int{1, 2}
+	_, err := vm.EvaluateSnippet("snippet", `std.extVar("x")`)
+	if err == nil || !strings.HasPrefix(err.Error(), "RUNTIME ERROR: External variable x: cannot convert value of type []int to a Jsonnet value") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+// TestNonFiniteNumbers checks that no public path creates a NaN or an
+// infinite number.
+func TestNonFiniteNumbers(t *testing.T) {
+	for _, v := range []interface{}{math.NaN(), math.Inf(1), float32(math.Inf(-1))} {
+		vm := MakeVM()
+		if err := vm.ExtData("x", []interface{}{v}); err == nil {
+			t.Errorf("ExtData(%v): expected an error", v)
+		}
+	}
+
+	tests := []struct {
+		snippet string
+		errMsg  string
+	}{
+		{`1e400`, "Could not parse floating point number"},
+		{`std.parseJson("1e400")`, "Failed to parse JSON"},
+		{`1e308 * 10`, "Overflow"},
+		{`std.native("nan")()`, "Native function nan returned invalid data: cannot convert NaN to a Jsonnet number"},
+	}
+	for _, test := range tests {
+		vm := MakeVM()
+		vm.NativeFunction(&NativeFunction{
+			Name:   "nan",
+			Params: ast.Identifiers{},
+			Func: func(args []interface{}) (interface{}, error) {
+				return math.NaN(), nil
+			},
+		})
+		output, err := vm.evaluateSnippet("non_finite", test.snippet)
+		if err == nil || !strings.Contains(err.Error(), test.errMsg) {
+			t.Errorf("%s: expected error containing %q, got %v (output %q)", test.snippet, test.errMsg, err, output)
+		}
+	}
+}
+
+func TestRuntimeErrorStackTrace(t *testing.T) {
+	vm := MakeVM()
+	snippet := "local inner(x) =\n  error \"bad \" + x;\nlocal outer(x) = inner(x + 1);\n{ a: outer(1) }\n"
+	_, err := vm.EvaluateSnippet("nested.jsonnet", snippet)
+	var runtimeErr RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("Expected a RuntimeError, got %#v", err)
+	}
+	if runtimeErr.Msg != "bad 2" {
+		t.Errorf("Unexpected message %#v", runtimeErr.Msg)
+	}
+	expected := []struct{ loc, name string }{
+		{"During manifestation", ""},
+		{"nested.jsonnet:4:6-14", "thunk <object_field>"},
+		{"nested.jsonnet:3:18-30", "function <anonymous>"},
+		{"nested.jsonnet:2:3-19", "function <anonymous>"},
+	}
+	if len(runtimeErr.StackTrace) != len(expected) {
+		t.Fatalf("Expected %d frames, got %#v", len(expected), runtimeErr.StackTrace)
+	}
+	for i, frame := range runtimeErr.StackTrace {
+		if frame.Loc.String() != expected[i].loc || frame.Name != expected[i].name {
+			t.Errorf("Frame %d: expected %v %#v, got %v %#v",
+				i, expected[i].loc, expected[i].name, frame.Loc.String(), frame.Name)
+		}
+	}
+	if !strings.HasPrefix(err.Error(), "RUNTIME ERROR: bad 2\n\tDuring manifestation") {
+		t.Errorf("Unexpected formatted message %#v", err.Error())
+	}
+}
+
+func TestFailedAssertionStackTrace(t *testing.T) {
+	vm := MakeVM()
+	_, err := vm.EvaluateSnippet("assert.jsonnet", `{ spec: { a: 1, assert self.a > 1 : "bad" } }`)
+	var runtimeErr RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("Expected a RuntimeError, got %#v", err)
+	}
+	if runtimeErr.Msg != "assertion failed at path spec: bad" {
+		t.Errorf("Unexpected message %#v", runtimeErr.Msg)
+	}
+	// The same frames as for the error raised by the assertion itself.
+	expected := []struct{ loc, name string }{
+		{"During manifestation", ""},
+		{"", "thunk <object_field>"},
+	}
+	if len(runtimeErr.StackTrace) != len(expected) {
+		t.Fatalf("Expected %d frames, got %#v", len(expected), runtimeErr.StackTrace)
+	}
+	for i, frame := range runtimeErr.StackTrace {
+		if frame.Loc.String() != expected[i].loc || frame.Name != expected[i].name {
+			t.Errorf("Frame %d: expected %v %#v, got %v %#v",
+				i, expected[i].loc, expected[i].name, frame.Loc.String(), frame.Name)
+		}
+	}
+}
+
+func TestFoldIndexInFileNamedStd(t *testing.T) {
+	// Calls made by the standard library are told apart by their place in
+	// its AST, a user file may have the same name.
+	vm := MakeVM()
+	_, err := vm.EvaluateSnippet("std.jsonnet", `std.foldl(function(acc, x) error "bad", [1, 2], 0)`)
+	var runtimeErr RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("Expected a RuntimeError, got %#v", err)
+	}
+	if runtimeErr.Msg != "bad (std.foldl, at index 0)" {
+		t.Errorf("Unexpected message %#v", runtimeErr.Msg)
+	}
+}
+
+func TestASCIIOutput(t *testing.T) {
+	snippet := `{ "ключ": ["zażółć", "a\u007fb\u0001", "😀", std.manifestJsonEx({ x: "☃" }, " ")] }`
+	tests := []struct {
+		asciiOutput bool
+		expected    string
+	}{
+		{false, `{ "ключ": [ "zażółć", "a\u007fb\u0001", "😀", "{\n \"x\": \"☃\"\n}" ] }`},
+		{true, `{ "\u043a\u043b\u044e\u0447": [ "za\u017c\u00f3\u0142\u0107", "a\u007fb\u0001", "\ud83d\ude00", ` +
+			`"{\n \"x\": \"\u2603\"\n}" ] }`},
+	}
+	for _, test := range tests {
+		vm := MakeVM()
+		vm.ASCIIOutput = test.asciiOutput
+		output, err := vm.evaluateSnippet("ascii_output", snippet)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if removeExcessiveWhitespace(output) != test.expected {
+			t.Errorf("ASCIIOutput = %v: expected %v, got %v",
+				test.asciiOutput, test.expected, removeExcessiveWhitespace(output))
+		}
+	}
+}
+
+// TestOutputEscapingIsNotObservable checks that the escaping options only
+// affect the final output, not the strings the program makes.
+func TestOutputEscapingIsNotObservable(t *testing.T) {
+	snippet := `local s = std.toString({ a: "<é😀>" }) + std.manifestJson(["&"]) + std.manifestYamlDoc({ b: "é\nx\n" });
+	{ s: s, length: std.length(s), md5: std.md5(s) }`
+	vm := MakeVM()
+	expected, err := vm.evaluateSnippet("escaping", snippet)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	vm.ASCIIOutput = true
+	vm.EscapeHTML = true
+	vm.EscapeRune = func(r rune) (string, bool) {
+		if r == '/' {
+			return `\/`, true
+		}
+		return "", false
+	}
+	output, err := vm.evaluateSnippet("escaping", snippet)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, c := range output {
+		if c > 0x7f {
+			t.Fatalf("Output is not ASCII: %s", output)
+		}
+	}
+	var expectedDecoded, decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(expected), &expectedDecoded); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	if err := json.Unmarshal([]byte(output), &decoded); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, expectedDecoded) {
+		t.Errorf("Expected %#v, got %#v", expectedDecoded, decoded)
+	}
+}
+
+func TestImportExtensions(t *testing.T) {
+	dir := "testdata/import_extensions/"
+	importer := &FileImporter{Extensions: []string{".libsonnet", ".jsonnet"}}
+	tests := []struct {
+		snippet  string
+		expected string
+		errMsg   string
+	}{
+		{snippet: `import "main.jsonnet"`,
+			expected: `{ "exact": "exact", "jsonnet": "data.jsonnet", "lib": "lib.libsonnet", "withExtension": "lib.libsonnet" }`},
+		{snippet: `import "both"`,
+			errMsg: `RUNTIME ERROR: Import "both" is ambiguous, it could be any of: ` +
+				`testdata/import_extensions/both.libsonnet, testdata/import_extensions/both.jsonnet`},
+		{snippet: `import "missing"`,
+			errMsg: `RUNTIME ERROR: Couldn't open import "missing": No match locally or in the Jsonnet library paths.`},
+	}
+	for _, test := range tests {
+		vm := MakeVM()
+		vm.Importer(importer)
+		output, err := vm.evaluateSnippet(dir+"test.jsonnet", test.snippet)
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		if errMsg != test.errMsg {
+			t.Errorf("%s: expected error %#v, got %#v", test.snippet, test.errMsg, errMsg)
+		}
+		if err == nil && removeExcessiveWhitespace(output) != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.snippet, test.expected, removeExcessiveWhitespace(output))
+		}
+	}
+
+	// Without extensions configured, the path must be complete.
+	vm := MakeVM()
+	_, err := vm.evaluateSnippet(dir+"test.jsonnet", `import "lib"`)
+	if err == nil {
+		t.Errorf("Expected an error importing a path without extension")
+	}
+}
+
+// urlImporter serves files by URL, resolving relative imports like a browser.
+type urlImporter map[string]string
+
+func (importer urlImporter) Import(codeDir, importedPath string) *ImportedData {
+	base, err := url.Parse(codeDir)
+	if err != nil {
+		return MakeImportedDataError(err)
+	}
+	ref, err := url.Parse(importedPath)
+	if err != nil {
+		return MakeImportedDataError(err)
+	}
+	foundHere := base.ResolveReference(ref).String()
+	content, ok := importer[foundHere]
+	if !ok {
+		return MakeImportedDataError(fmt.Errorf("Couldn't fetch %s", foundHere))
+	}
+	return MakeImportedData(content, foundHere)
+}
+
+func TestImportURL(t *testing.T) {
+	importer := urlImporter{
+		"mem://host/lib/a.libsonnet":      `{ name: "a", b: import "b.libsonnet", data: importstr "../data.txt", missing:: import "sub/missing.libsonnet" }`,
+		"mem://host/lib/b.libsonnet":      `{ name: "b", c: (import "./sub/c.libsonnet").name }`,
+		"mem://host/lib/sub/c.libsonnet":  `{ name: "c" }`,
+		"mem://host/lib/broken.libsonnet": `{ name: }`,
+		"mem://host/data.txt":             "data",
+	}
+	tests := []struct {
+		snippet  string
+		expected string
+		errMsg   string
+	}{
+		{snippet: `import "mem://host/lib/a.libsonnet"`,
+			expected: `{ "b": { "c": "c", "name": "b" }, "data": "data", "name": "a" }`},
+		// The URL is the file name in error messages.
+		{snippet: `import "mem://host/lib/broken.libsonnet"`,
+			errMsg: "mem://host/lib/broken.libsonnet:1:"},
+		{snippet: `(import "mem://host/lib/a.libsonnet").missing`,
+			errMsg: "Couldn't fetch mem://host/lib/sub/missing.libsonnet"},
+	}
+	for _, test := range tests {
+		vm := MakeVM()
+		vm.Importer(importer)
+		output, err := vm.evaluateSnippet("main.jsonnet", test.snippet)
+		if test.errMsg != "" {
+			if err == nil || !strings.Contains(err.Error(), test.errMsg) {
+				t.Errorf("%s: expected error containing %q, got %v", test.snippet, test.errMsg, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.snippet, err)
+			continue
+		}
+		if removeExcessiveWhitespace(output) != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.snippet, test.expected, removeExcessiveWhitespace(output))
+		}
+	}
+}
+
+func TestNegativeSliceIndices(t *testing.T) {
+	tests := []struct {
+		snippet  string
+		expected string
+		errMsg   string
+	}{
+		{`[1, 2, 3, 4][-2:]`, `[ 3, 4 ]`, ""},
+		{`"hello"[:-1]`, `"hell"`, ""},
+		{`[1, 2, 3, 4][-100:2]`, `[ 1, 2 ]`, ""},
+		{`[1, 2, 3, 4][1:-100]`, `[ ]`, ""},
+		{`"hello"[-4:-1:2]`, `"el"`, ""},
+		{`[1, 2, 3][::-1]`, "", "RUNTIME ERROR: got [0:3:-1] but negative index, end, and steps are not supported"},
+	}
+	for _, test := range tests {
+		vm := MakeVM()
+		vm.NegativeSliceIndices = true
+		output, err := vm.evaluateSnippet("negative_slice", test.snippet)
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		if errMsg != test.errMsg {
+			t.Errorf("%s: expected error %#v, got %#v", test.snippet, test.errMsg, errMsg)
+		}
+		if err == nil && removeExcessiveWhitespace(output) != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.snippet, test.expected, removeExcessiveWhitespace(output))
+		}
+	}
+}
+
+func TestUpstreamNumberFormat(t *testing.T) {
+	tests := []struct {
+		snippet  string
+		upstream string
+		shortest string
+	}{
+		{`0.1`, `0.10000000000000001`, `0.1`},
+		{`-0.5`, `-0.5`, `-0.5`},
+		{`1e-7`, `9.9999999999999995e-08`, `1e-07`},
+		{`1/1e30`, `9.9999999999999991e-31`, `9.999999999999999e-31`},
+		{`std.atan(1)`, `0.78539816339744828`, `0.7853981633974483`},
+		{`std.cos(1)`, `0.54030230586813977`, `0.5403023058681398`},
+		{`std.mantissa(0.42)`, `0.83999999999999997`, `0.84`},
+		{`1e21`, `1000000000000000000000`, `1000000000000000000000`},
+		{`42`, `42`, `42`},
+		{`"" + 0.1`, `"0.10000000000000001"`, `"0.1"`},
+		{`std.toString([0.2])`, `"[0.20000000000000001]"`, `"[0.2]"`},
+	}
+	for _, test := range tests {
+		for _, upstream := range []bool{true, false} {
+			vm := MakeVM()
+			vm.UpstreamNumberFormat = upstream
+			output, err := vm.evaluateSnippet("number_format", test.snippet)
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", test.snippet, err)
+			}
+			expected := test.shortest
+			if upstream {
+				expected = test.upstream
+			}
+			if strings.TrimSpace(output) != expected {
+				t.Errorf("%s with UpstreamNumberFormat = %v: expected %v, got %v",
+					test.snippet, upstream, expected, strings.TrimSpace(output))
+			}
+		}
+	}
+}
+
+// TestNumberFormat pins the exact output of numbers, which must not depend on
+// the platform or the Go version.
+func TestNumberFormat(t *testing.T) {
+	tests := []struct {
+		number   float64
+		shortest string
+		upstream string
+	}{
+		{0, "0", "0"},
+		{math.Copysign(0, -1), "-0", "-0"},
+		{-2, "-2", "-2"},
+		{0.5, "0.5", "0.5"},
+		{0.1, "0.1", "0.10000000000000001"},
+		{0.3, "0.3", "0.29999999999999999"},
+		{2.0 / 3, "0.6666666666666666", "0.66666666666666663"},
+		{1.0000000000000002, "1.0000000000000002", "1.0000000000000002"},
+		{0.0001, "0.0001", "0.0001"},
+		{0.00012, "0.00012", "0.00012"},
+		{1.5e-5, "1.5e-05", "1.5e-05"},
+		{1e-7, "1e-07", "9.9999999999999995e-08"},
+		{-1e-300, "-1e-300", "-1e-300"},
+		{5e-324, "5e-324", "4.9406564584124654e-324"},
+		{123456.5, "123456.5", "123456.5"},
+		{1234567.5, "1.2345675e+06", "1234567.5"},
+		{1e15 + 0.5, "1.0000000000000005e+15", "1000000000000000.5"},
+		{1e16, "10000000000000000", "10000000000000000"},
+		{1e21, "1000000000000000000000", "1000000000000000000000"},
+		{1.2345678901234568e+20, "123456789012345683968", "123456789012345683968"},
+		{1e300, "1000000000000000052504760255204420248704468581108159154915854115511802457988908195786371375080447864043704443832883878176942523235360430575644792184786706982848387200926575803737830233794788090059368953234970799945081119038967640880074652742780142494579258788820056842838115669472196386865459400540160",
+			"1000000000000000052504760255204420248704468581108159154915854115511802457988908195786371375080447864043704443832883878176942523235360430575644792184786706982848387200926575803737830233794788090059368953234970799945081119038967640880074652742780142494579258788820056842838115669472196386865459400540160"},
+	}
+	for _, test := range tests {
+		if got := unparseNumber(test.number); got != test.shortest {
+			t.Errorf("unparseNumber(%v): expected %v, got %v", test.number, test.shortest, got)
+		}
+		if got := unparseNumberUpstream(test.number); got != test.upstream {
+			t.Errorf("unparseNumberUpstream(%v): expected %v, got %v", test.number, test.upstream, got)
+		}
+	}
+}
+
+func TestWarnUnusedLocals(t *testing.T) {
+	snippet := "local used = 1, unused = 2;\nused"
+	for _, enabled := range []bool{false, true} {
+		var warnings []string
+		vm := MakeVM()
+		vm.WarnUnusedLocals = enabled
+		vm.WarningHandler = func(w Warning) {
+			warnings = append(warnings, w.String())
+		}
+		output, err := vm.EvaluateSnippet("warnings", snippet)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if output != "1" {
+			t.Errorf("expected output 1, got %v", output)
+		}
+		var expected []string
+		if enabled {
+			expected = []string{"warnings:1:26-27 WARNING: Unused local variable: unused"}
+		}
+		if !reflect.DeepEqual(warnings, expected) {
+			t.Errorf("with WarnUnusedLocals = %v: expected warnings %v, got %v", enabled, expected, warnings)
+		}
+	}
+}
+
+func TestEscapeRune(t *testing.T) {
+	snippet := `{ "a/b": "</script>\u2028\u2029\n" }`
+	vm := MakeVM()
+	output, err := vm.evaluateSnippet("escape_rune", snippet)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "{\n   \"a/b\": \"</script>\u2028\u2029\\n\"\n}"
+	if output != expected {
+		t.Errorf("expected %v, got %v", expected, output)
+	}
+
+	vm.EscapeRune = func(r rune) (string, bool) {
+		switch r {
+		case '/':
+			return `\/`, true
+		case '\u2028', '\u2029':
+			return fmt.Sprintf(`\u%04x`, r), true
+		}
+		return "", false
+	}
+	output, err = vm.evaluateSnippet("escape_rune", snippet)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = "{\n   \"a\\/b\": \"<\\/script>\\u2028\\u2029\\n\"\n}"
+	if output != expected {
+		t.Errorf("expected %v, got %v", expected, output)
+	}
+	var decoded map[string]string
+	if err := json.Unmarshal([]byte(output), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if decoded["a/b"] != "</script>\u2028\u2029\n" {
+		t.Errorf("unexpected decoded output %#v", decoded)
+	}
+}
+
+func TestEscapeHTML(t *testing.T) {
+	// The string manifested by std.manifestJson is only escaped in the output.
+	snippet := `{ "<k>": "<script>a && b</script>", nested: std.manifestJson(["&"]) }`
+	tests := []struct {
+		name       string
+		escapeHTML bool
+		escapeRune func(rune) (string, bool)
+		expected   string
+	}{
+		{"off", false, nil,
+			"{\n   \"<k>\": \"<script>a && b</script>\",\n   \"nested\": \"[\\n    \\\"&\\\"\\n]\"\n}"},
+		{"on", true, nil,
+			"{\n   \"\\u003ck\\u003e\": \"\\u003cscript\\u003ea \\u0026\\u0026 b\\u003c/script\\u003e\",\n" +
+				"   \"nested\": \"[\\n    \\\"\\u0026\\\"\\n]\"\n}"},
+		{"withEscapeRune", true, func(r rune) (string, bool) {
+			if r == '/' || r == '<' {
+				return `\/`, true
+			}
+			return "", false
+		}, "{\n   \"\\u003ck\\u003e\": \"\\u003cscript\\u003ea \\u0026\\u0026 b\\u003c\\/script\\u003e\",\n" +
+			"   \"nested\": \"[\\n    \\\"\\u0026\\\"\\n]\"\n}"},
+	}
+	for _, test := range tests {
+		vm := MakeVM()
+		vm.EscapeHTML = test.escapeHTML
+		vm.EscapeRune = test.escapeRune
+		output, err := vm.evaluateSnippet(test.name, snippet)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if output != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, output)
+		}
+		var decoded map[string]string
+		if err := json.Unmarshal([]byte(output), &decoded); err != nil {
+			t.Fatalf("%s: output is not valid JSON: %v", test.name, err)
+		}
+		if decoded["<k>"] != "<script>a && b</script>" {
+			t.Errorf("%s: unexpected decoded output %#v", test.name, decoded)
+		}
+	}
+}
+
+func TestLineEndingsAndBOM(t *testing.T) {
+	snippet := `{ a: [1, 2], b: "x\ny", c: {} }`
+	lf := "{\n   \"a\": [\n      1,\n      2\n   ],\n   \"b\": \"x\\ny\",\n   \"c\": { }\n}"
+	tests := []struct {
+		crlf, bom bool
+		expected  string
+	}{
+		{false, false, lf},
+		{true, false, strings.Replace(lf, "\n", "\r\n", -1)},
+		{false, true, "\ufeff" + lf},
+		{true, true, "\ufeff" + strings.Replace(lf, "\n", "\r\n", -1)},
+	}
+	for _, test := range tests {
+		vm := MakeVM()
+		vm.CRLFLineEndings = test.crlf
+		vm.UTF8BOM = test.bom
+		output, err := vm.EvaluateSnippet("line_endings", snippet)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if output != test.expected {
+			t.Errorf("with CRLFLineEndings = %v, UTF8BOM = %v: expected %q, got %q",
+				test.crlf, test.bom, test.expected, output)
+		}
+	}
+
+	// Strings produced by std.manifestJsonEx are values, not the output.
+	vm := MakeVM()
+	vm.CRLFLineEndings = true
+	output, err := vm.EvaluateSnippet("line_endings", `std.manifestJsonEx({ a: 1 }, "  ")`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := `"{\n  \"a\": 1\n}"`; output != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	snippet := "local x = std.trace(\"x is \" + 1, 1);\n{ a: std.trace(\"a \\\"quoted\\\"\", x + 1) }"
+	tests := []struct {
+		name     string
+		disable  bool
+		json     bool
+		snippet  string
+		expected string
+	}{
+		{"text", false, false, snippet, "TRACE: trace:2 a \"quoted\"\nTRACE: trace:1 x is 1\n"},
+		{"json", false, true, snippet,
+			`{"file":"trace","line":2,"message":"a \"quoted\""}` + "\n" +
+				`{"file":"trace","line":1,"message":"x is 1"}` + "\n"},
+		{"disabled", true, false, snippet, ""},
+		{"disabledLazy", true, false, `{ a: std.trace(error "message evaluated", 1) + 1 }`, ""},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		vm := MakeVM()
+		vm.DisableTrace = test.disable
+		vm.TraceJSON = test.json
+		vm.TraceOut = &out
+		output, err := vm.evaluateSnippet("trace", test.snippet)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if expected := "{\n   \"a\": 2\n}"; output != expected {
+			t.Errorf("%s: expected output %v, got %v", test.name, expected, output)
+		}
+		if out.String() != test.expected {
+			t.Errorf("%s: expected trace %q, got %q", test.name, test.expected, out.String())
+		}
+	}
+}
+
+// TestManifestReplacerCalls checks that the replacer of std.manifestJsonEx is
+// called once per value, even though arrays which don't fit in
+// compactArrayWidth are written twice.
+func TestManifestReplacerCalls(t *testing.T) {
+	var out bytes.Buffer
+	vm := MakeVM()
+	vm.TraceOut = &out
+	snippet := `std.manifestJsonEx([[["aaaaaaaaaa", "bbbbbbbbbbbb"]]], " ", 5, false, null, null, function(k, v) std.trace("replacer", v))`
+	if _, err := vm.evaluateSnippet("replacer", snippet); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The top-level value, two arrays and two strings.
+	if calls := strings.Count(out.String(), "TRACE:"); calls != 5 {
+		t.Errorf("expected 5 calls of the replacer, got %d:\n%s", calls, out.String())
+	}
+}
+
+func TestTraceIndent(t *testing.T) {
+	var out bytes.Buffer
+	vm := MakeVM()
+	vm.TraceIndent = true
+	vm.TraceOut = &out
+	snippet := "local inner(x) = std.trace(\"inner\", x);\n" +
+		"local outer(x) = std.trace(\"outer\", inner(x) + 1);\n" +
+		"{ a: std.trace(\"top\", outer(1)) }"
+	if _, err := vm.evaluateSnippet("trace", snippet); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	messages := []string{"top", "outer", "inner"}
+	if len(lines) != len(messages) {
+		t.Fatalf("expected %d trace lines, got %q", len(messages), out.String())
+	}
+	previousIndent := -1
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if !strings.HasPrefix(trimmed, "TRACE: ") || !strings.HasSuffix(trimmed, " "+messages[i]) {
+			t.Fatalf("expected trace of %q, got %q", messages[i], line)
+		}
+		indent := len(line) - len(trimmed)
+		if indent <= previousIndent {
+			t.Errorf("expected %q to be indented more than the previous trace, got %q", messages[i], out.String())
+		}
+		previousIndent = indent
+	}
+}
+
+func TestNativeFunction(t *testing.T) {
+	sum := sha256.Sum256([]byte("abc"))
+	hashBytes := make([]string, len(sum))
+	for i, b := range sum {
+		hashBytes[i] = fmt.Sprint(b)
+	}
+	natives := []*NativeFunction{
+		{
+			Name:        "sha256",
+			Params:      ast.Identifiers{"data"},
+			BytesParams: ast.Identifiers{"data"},
+			Func: func(args []interface{}) (interface{}, error) {
+				sum := sha256.Sum256(args[0].([]byte))
+				return sum[:], nil
+			},
+		},
+		{
+			Name:   "describe",
+			Params: ast.Identifiers{"x", "raw"},
+			Func: func(args []interface{}) (interface{}, error) {
+				return map[string]interface{}{"x": fmt.Sprintf("%T %v", args[0], args[0]), "raw": args[1]}, nil
+			},
+		},
+		{
+			Name:   "fail",
+			Params: ast.Identifiers{},
+			Func: func(args []interface{}) (interface{}, error) {
+				return nil, errors.New("native failure")
+			},
+		},
+	}
+	tests := []struct {
+		name     string
+		snippet  string
+		expected string
+		errMsg   string
+	}{
+		{"bytes", `std.native("sha256")([97, 98, 99])`, "[\n   " + strings.Join(hashBytes, ",\n   ") + "\n]", ""},
+		{"emptyBytes", `std.length(std.native("sha256")([]))`, "32", ""},
+		{"bytesParamOnly", `std.native("describe")([1, 2], { a:: 1, b: [null, true, "s"] })`,
+			"{\n   \"raw\": {\n      \"b\": [\n         null,\n         true,\n         \"s\"\n      ]\n   },\n   \"x\": \"[]interface {} [1 2]\"\n}", ""},
+		{"missing", `std.native("nope")`, "null", ""},
+		{"notByte", `std.native("sha256")([1, 256])`, "", "Expected a byte (an integer from 0 to 255) at index 1, got 256"},
+		{"notInteger", `std.native("sha256")([0.5])`, "", "Expected a byte (an integer from 0 to 255) at index 0, got 0.5"},
+		{"notArray", `std.native("sha256")("abc")`, "", "Unexpected type string, expected array"},
+		{"function", `std.native("describe")(function() 1, null)`, "", "Cannot convert function to Go data"},
+		{"error", `std.native("fail")()`, "", "native failure"},
+		{"recursive", `local o = { a: o }; std.native("describe")(1, o)`, "", "manifestation exceeded maximum depth"},
+	}
+	for _, test := range tests {
+		vm := MakeVM()
+		for _, f := range natives {
+			vm.NativeFunction(f)
+		}
+		output, err := vm.evaluateSnippet(test.name, test.snippet)
+		if test.errMsg != "" {
+			if err == nil || !strings.Contains(err.Error(), test.errMsg) {
+				t.Errorf("%s: expected error containing %q, got %v", test.name, test.errMsg, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if output != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, output)
+		}
+	}
+}
+
+// TestFieldMemoization checks that a field accessed several times is
+// evaluated only once, including through self, super and builtins.
+func TestFieldMemoization(t *testing.T) {
+	tests := []struct {
+		name     string
+		snippet  string
+		expected string
+	}{
+		{"index", `local o = { x: std.native("count")() }; o.x + o.x + o.x`, "3"},
+		{"self", `{ x: std.native("count")(), y: self.x + self.x }.y`, "2"},
+		{"inherited", `local o = { x: std.native("count")() } + { y: self.x + super.x }; o.y + o.x`, "3"},
+		{"builtins", `local o = { x: std.native("count")() }; std.objectValues(o)[0] + o["x"] + o.x`, "3"},
+	}
+	for _, test := range tests {
+		calls := 0
+		vm := MakeVM()
+		vm.NativeFunction(&NativeFunction{
+			Name:   "count",
+			Params: ast.Identifiers{},
+			Func: func(args []interface{}) (interface{}, error) {
+				calls++
+				return 1.0, nil
+			},
+		})
+		output, err := vm.evaluateSnippet(test.name, test.snippet)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if output != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, output)
+		}
+		if calls != 1 {
+			t.Errorf("%s: expected the field to be evaluated once, got %d evaluations", test.name, calls)
+		}
+	}
+}
+
+func TestStrictEvaluation(t *testing.T) {
+	snippet := `
+local a = std.trace("a", 1);
+local b = std.trace("b", 2);
+local f(x, y) = y + x;
+[b, a, f(std.trace("x", 1), std.trace("y", 2)), [std.trace("e1", 1), std.trace("e2", 0)][1]]`
+	tests := []struct {
+		name     string
+		strict   bool
+		expected string
+	}{
+		// Traces fire when the output needs the values.
+		{"lazy", false, "b a y x e2"},
+		// Traces fire in source order.
+		{"strict", true, "a b x y e1 e2"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		vm := MakeVM()
+		vm.StrictEvaluation = test.strict
+		vm.TraceOut = &out
+		output, err := vm.evaluateSnippet("order", snippet)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if expected := "[\n   2,\n   1,\n   3,\n   0\n]"; output != expected {
+			t.Errorf("%s: expected output %v, got %v", test.name, expected, output)
+		}
+		var order []string
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			order = append(order, line[strings.LastIndex(line, " ")+1:])
+		}
+		if got := strings.Join(order, " "); got != test.expected {
+			t.Errorf("%s: expected traces in order %q, got %q", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestExactJSONIntegers(t *testing.T) {
+	tests := []struct {
+		name     string
+		snippet  string
+		expected string
+		errMsg   string
+	}{
+		{"inexact", `std.parseJson("9007199254740993")`, "",
+			"Failed to parse JSON: integer 9007199254740993 can't be represented exactly as a number, the nearest is 9007199254740992"},
+		{"inexactNested", `std.parseJson('{"a": [1, -9007199254740993]}')`, "",
+			"integer -9007199254740993 can't be represented exactly"},
+		{"exact", `std.parseJson("[9007199254740992, 18014398509481984, -1, 0]")`,
+			"[\n   9007199254740992,\n   18014398509481984,\n   -1,\n   0\n]", ""},
+		// Only integers are checked, other numbers are rounded as usual.
+		{"fractions", `std.parseJson("[0.1, 2.5e2, 9007199254740993.0]")`,
+			"[\n   0.1,\n   250,\n   9007199254740992\n]", ""},
+	}
+	for _, test := range tests {
+		vm := MakeVM()
+		vm.ExactJSONIntegers = true
+		output, err := vm.evaluateSnippet(test.name, test.snippet)
+		if test.errMsg != "" {
+			if err == nil || !strings.Contains(err.Error(), test.errMsg) {
+				t.Errorf("%s: expected error containing %q, got %v", test.name, test.errMsg, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if output != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, output)
+		}
+	}
+}
+
+func TestStrictBitwise(t *testing.T) {
+	tests := []struct {
+		name     string
+		strict   bool
+		snippet  string
+		expected string
+		errMsg   string
+	}{
+		// By default the operands are truncated towards zero.
+		{"truncated", false, `[5.7 & 3, -5.7 | 0, ~1.5, 1.9 << 2.9]`, "[\n   1,\n   -5,\n   -2,\n   4\n]", ""},
+		{"fraction", true, `5.7 & 3`, "",
+			"Bitwise operator & expects an integer in [-(2^53 - 1), 2^53 - 1], got 5.7"},
+		{"fractionRight", true, `3 ^ 0.5`, "", "Bitwise operator ^ expects an integer"},
+		{"fractionNegation", true, `~1.5`, "", "Bitwise operator ~ expects an integer"},
+		{"unsafe", true, `9007199254740992 | 0`, "", "got 9007199254740992"},
+		{"negativeShift", true, `1 << -1`, "", "Shift amount for operator << should be in [0, 63], got -1"},
+		{"largeShift", true, `1 >> 64`, "", "Shift amount for operator >> should be in [0, 63], got 64"},
+		{"integers", true, `[5 & 3, -5 | 2, 6 ^ 3, ~0, 1 << 52, -8 >> 1, 9007199254740991 & 1]`,
+			"[\n   1,\n   -5,\n   5,\n   -1,\n   4503599627370496,\n   -4,\n   1\n]", ""},
+	}
+	for _, test := range tests {
+		vm := MakeVM()
+		vm.StrictBitwise = test.strict
+		output, err := vm.evaluateSnippet(test.name, test.snippet)
+		if test.errMsg != "" {
+			if err == nil || !strings.Contains(err.Error(), test.errMsg) {
+				t.Errorf("%s: expected error containing %q, got %v", test.name, test.errMsg, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if output != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, output)
+		}
+	}
+}
+
+func TestAnnotateFieldSources(t *testing.T) {
+	snippet := "local lib = {\n  c: 'c',\n};\n" +
+		"{\n  a: 1,\n  b: { x: [1, 2] },\n} + lib + {\n  d+: 3,\n  e: std.mapWithKey(function(k, v) v, { f: 1 }),\n}"
+	vm := MakeVM()
+	vm.AnnotateFieldSources = true
+	output, err := vm.evaluateSnippet("annotate", snippet)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{
+   // from annotate:5:6-7
+   "a": 1,
+   // from annotate:6:6-19
+   "b": {
+      "x": [
+         1,
+         2
+      ]
+   },
+   // from annotate:2:7-8
+   "c": "c",
+   // from annotate:8:7-8
+   "d": 3,
+   // from annotate:9:6-48
+   "e": {
+      "f": 1
+   }
+}`
+	if output != expected {
+		t.Errorf("expected %v, got %v", expected, output)
+	}
+}
+
+func TestAnnotateFieldSourcesOutsideUserCode(t *testing.T) {
+	// Fields defined in std.jsonnet or by builtins have no annotation, even
+	// if their values come from user code.
+	snippet := "std.mergePatch({ a: 1 }, { b: 2 }) + std.pick({ p: 1, q: 2 }, ['p']) + {\n  c: 3,\n}"
+	vm := MakeVM()
+	vm.AnnotateFieldSources = true
+	output, err := vm.evaluateSnippet("annotate", snippet)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{
+   "a": 1,
+   "b": 2,
+   // from annotate:2:6-7
+   "c": 3,
+   "p": 1
+}`
+	if output != expected {
+		t.Errorf("expected %v, got %v", expected, output)
+	}
+}
+
+func TestBase64RoundTrip(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	inputs := make([][]byte, 200)
+	for i := range inputs {
+		inputs[i] = make([]byte, random.Intn(50))
+		random.Read(inputs[i])
+	}
+	// Marshalled as arrays of numbers, not as base64 strings.
+	arrays := make([][]int, len(inputs))
+	for i, input := range inputs {
+		arrays[i] = make([]int, len(input))
+		for j, b := range input {
+			arrays[i][j] = int(b)
+		}
+	}
+	arraysJSON, err := json.Marshal(arrays)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snippet := fmt.Sprintf(`[
+		local encoded = std.base64(b);
+		{
+			encoded: encoded,
+			decodedBytes: std.base64DecodeBytes(encoded),
+			decodedString: std.base64Decode(encoded) == std.join("", std.map(std.char, b)),
+		}
+		for b in %s
+	]`, arraysJSON)
+	vm := MakeVM()
+	output, err := vm.evaluateSnippet("base64", snippet)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var results []struct {
+		Encoded       string
+		DecodedBytes  []int
+		DecodedString bool
+	}
+	if err := json.Unmarshal([]byte(output), &results); err != nil {
+		t.Fatal(err)
+	}
+	for i, input := range inputs {
+		result := results[i]
+		if expected := base64.StdEncoding.EncodeToString(input); result.Encoded != expected {
+			t.Errorf("std.base64(%v): expected %q, got %q", arrays[i], expected, result.Encoded)
+		}
+		if !reflect.DeepEqual(result.DecodedBytes, arrays[i]) {
+			t.Errorf("std.base64DecodeBytes(%q): expected %v, got %v", result.Encoded, arrays[i], result.DecodedBytes)
+		}
+		if !result.DecodedString {
+			t.Errorf("std.base64Decode(%q) does not return the encoded string", result.Encoded)
+		}
+	}
+}
+
+func TestEvaluateAST(t *testing.T) {
+	identifier := func(name string) *ast.Identifier {
+		id := ast.Identifier(name)
+		return &id
+	}
+	number := func(v float64) ast.Node {
+		return &ast.LiteralNumber{Value: v, OriginalString: fmt.Sprint(v)}
+	}
+	// local x = 20; { answer: x * 2 + 2, len: std.length([x, x]) }
+	node := &ast.Local{
+		Binds: ast.LocalBinds{{Variable: "x", Body: number(20)}},
+		Body: &ast.Object{Fields: ast.ObjectFields{
+			{
+				Kind: ast.ObjectFieldID,
+				Hide: ast.ObjectFieldInherit,
+				Id:   identifier("answer"),
+				Expr2: &ast.Binary{
+					Left:  &ast.Binary{Left: &ast.Var{Id: "x"}, Op: ast.BopMult, Right: number(2)},
+					Op:    ast.BopPlus,
+					Right: number(2),
+				},
+			},
+			{
+				Kind: ast.ObjectFieldID,
+				Hide: ast.ObjectFieldInherit,
+				Id:   identifier("len"),
+				Expr2: &ast.Apply{
+					Target: &ast.Index{Target: &ast.Var{Id: "std"}, Id: identifier("length")},
+					Arguments: ast.Arguments{Positional: ast.Nodes{
+						&ast.Array{Elements: ast.Nodes{&ast.Var{Id: "x"}, &ast.Var{Id: "x"}}},
+					}},
+				},
+			},
+		}},
+	}
+	vm := MakeVM()
+	output, err := vm.Evaluate(node)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "{\n   \"answer\": 42,\n   \"len\": 2\n}"; output != expected {
+		t.Errorf("expected %v, got %v", expected, output)
+	}
+
+	// The AST is desugared in place, but it can be evaluated again.
+	again, err := vm.Evaluate(node)
+	if err != nil || again != output {
+		t.Errorf("expected the same output again, got %q (error %v)", again, err)
+	}
+
+	// The AST is checked statically before the evaluation.
+	_, err = vm.Evaluate(&ast.Binary{Left: &ast.Var{Id: "y"}, Op: ast.BopPlus, Right: number(1)})
+	if err == nil || !strings.Contains(err.Error(), "Unknown variable: y") {
+		t.Errorf("expected an unknown variable error, got %v", err)
+	}
+
+	_, err = vm.Evaluate(&ast.Error{Expr: &ast.LiteralString{Value: "built by hand", Kind: ast.StringDouble}})
+	if err == nil || !strings.Contains(err.Error(), "RUNTIME ERROR: built by hand") {
+		t.Errorf("expected a runtime error, got %v", err)
+	}
+
+	_, err = vm.Evaluate(nil)
+	if err == nil || err.Error() != "Evaluate needs an AST, got nil" {
+		t.Errorf("expected an error for a nil AST, got %v", err)
+	}
+
+	_, err = vm.Evaluate((*ast.Object)(nil))
+	if err == nil || err.Error() != "Evaluate needs an AST, got nil" {
+		t.Errorf("expected an error for a typed nil AST, got %v", err)
+	}
+}
+
+func TestInternalErrorsDontCrash(t *testing.T) {
+	// An operator which doesn't exist, as a buggy tool building an AST might
+	// produce. Operators are assumed to be valid past the parser.
+	node := &ast.Binary{
+		Left:  &ast.LiteralNumber{Value: 1, OriginalString: "1"},
+		Op:    ast.BinaryOp(1000),
+		Right: &ast.LiteralNumber{Value: 2, OriginalString: "2"},
+	}
+	vm := MakeVM()
+	_, err := vm.Evaluate(node)
+	if err == nil || !strings.Contains(err.Error(), "(CRASH)") {
+		t.Errorf("expected a crash error, got %v", err)
+	}
+
+	// The VM is still usable afterwards.
+	output, err := vm.EvaluateSnippet("test", "1 + 2")
+	if err != nil || output != "3" {
+		t.Errorf("expected 3, got %q (error %v)", output, err)
+	}
+}
+
+func TestTopLevelTypes(t *testing.T) {
+	tests := []struct {
+		snippet  string
+		expected string
+	}{
+		{`[1, "é", [], {}]`, "[\n   1,\n   \"é\",\n   [ ],\n   { }\n]"},
+		{`[]`, "[ ]"},
+		{`{}`, "{ }"},
+		{`42`, "42"},
+		{`"s"`, `"s"`},
+		{`true`, "true"},
+		{`false`, "false"},
+		{`null`, "null"},
+	}
+	for _, test := range tests {
+		vm := MakeVM()
+		output, err := vm.EvaluateSnippet("top_level", test.snippet)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.snippet, err)
+		} else if output != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.snippet, test.expected, output)
+		}
+
+		// The output options apply to any top-level value, not just objects.
+		vm.ASCIIOutput = true
+		vm.UpstreamNumberFormat = true
+		vm.CRLFLineEndings = true
+		expected := strings.Replace(strings.Replace(test.expected, "é", `\u00e9`, -1), "\n", "\r\n", -1)
+		output, err = vm.EvaluateSnippet("top_level", test.snippet)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.snippet, err)
+		} else if output != expected {
+			t.Errorf("%s with output options: expected %q, got %q", test.snippet, expected, output)
+		}
+	}
+}
+
+func TestStaticTypeChecks(t *testing.T) {
+	snippet := `local unused = null.x; 1`
+	vm := MakeVM()
+	output, err := vm.EvaluateSnippet("static_types", snippet)
+	if err != nil || output != "1" {
+		t.Errorf("without StaticTypeChecks: expected 1, got %q (error %v)", output, err)
+	}
+	vm.StaticTypeChecks = true
+	_, err = vm.EvaluateSnippet("static_types", snippet)
+	if err == nil || !strings.Contains(err.Error(), "static_types:1:16-22 Value non indexable: null") {
+		t.Errorf("with StaticTypeChecks: expected a static error, got %v", err)
+	}
+}
@@ -668,10 +668,18 @@ func (l *lexer) lexSymbol() error {
 	return nil
 }
 
-func Lex(fn string, input string) (tokens, error) {
+// Lex splits input into tokens. Like Parse, it reports a bug in the lexer as
+// an error instead of a panic, except in builds with the jsonnet_debug tag.
+func Lex(fn string, input string) (toks tokens, err error) {
 	l := makeLexer(fn, input)
-
-	var err error
+	defer func() {
+		if r := recover(); r != nil {
+			if debugPanics {
+				panic(r)
+			}
+			toks, err = nil, MakeStaticErrorPoint(internalErrorMsg(r), fn, l.location())
+		}
+	}()
 
 	for r := l.next(); r != lexEOF; r = l.next() {
 		switch r {
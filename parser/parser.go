@@ -19,6 +19,7 @@ package parser
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/google/go-jsonnet/ast"
 )
@@ -1148,8 +1149,34 @@ func (p *parser) parse(prec precedence) (ast.Node, error) {
 
 // ---------------------------------------------------------------------------
 
-func Parse(t tokens) (ast.Node, error) {
+// internalErrorMsg describes a panic recovered in Lex or Parse. Some panics
+// are already prefixed with INTERNAL ERROR, so it isn't repeated.
+func internalErrorMsg(r interface{}) string {
+	return "INTERNAL ERROR: " + strings.TrimPrefix(fmt.Sprint(r), "INTERNAL ERROR: ")
+}
+
+// internalError converts a panic in the parser to a StaticError at the token
+// being parsed.
+func (p *parser) internalError(r interface{}) StaticError {
+	msg := internalErrorMsg(r)
+	if p.currT < len(p.t) {
+		return MakeStaticError(msg, p.t[p.currT].loc)
+	}
+	return MakeStaticErrorMsg(msg)
+}
+
+// Parse parses the tokens returned by Lex. A bug in the parser is reported as
+// an error instead of a panic, except in builds with the jsonnet_debug tag.
+func Parse(t tokens) (node ast.Node, err error) {
 	p := makeParser(t)
+	defer func() {
+		if r := recover(); r != nil {
+			if debugPanics {
+				panic(r)
+			}
+			node, err = nil, p.internalError(r)
+		}
+	}()
 	expr, err := p.parse(maxPrecedence)
 	if err != nil {
 		return nil, err
@@ -16,6 +16,7 @@ limitations under the License.
 package parser
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -262,3 +263,16 @@ func TestParserErrors(t *testing.T) {
 	}
 
 }
+
+func TestParserInternalError(t *testing.T) {
+	// Lex always ends the tokens with an end of file token, which the
+	// parser relies on. Without it, the parser runs off the end of the tokens.
+	tokens, err := Lex("test", "[1, 2")
+	if err != nil {
+		t.Fatalf("Unexpected lex error: %v", err)
+	}
+	_, err = Parse(tokens[:len(tokens)-1])
+	if err == nil || !strings.Contains(err.Error(), "INTERNAL ERROR: ") {
+		t.Errorf("Expected an internal error, got %v", err)
+	}
+}
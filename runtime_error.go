@@ -18,10 +18,20 @@ package jsonnet
 
 import "github.com/google/go-jsonnet/ast"
 
-// RuntimeError is an error discovered during evaluation of the program
+// RuntimeError is an error discovered during evaluation of the program.
+//
+// Errors returned by VM.EvaluateSnippet wrap it, so embedders can get it with
+// errors.As to render their own diagnostics.
 type RuntimeError struct {
+	// StackTrace starts with the outermost frame and ends with the place
+	// where the error was raised.
 	StackTrace []TraceFrame
 	Msg        string
+	// Whether Msg already says at which element a fold failed.
+	hasElementIndex bool
+	// Whether the error is a failed object assertion, as opposed to an error
+	// raised while checking one.
+	assertionFailed bool
 }
 
 func makeRuntimeError(msg string, stackTrace []TraceFrame) RuntimeError {
@@ -40,7 +50,10 @@ func (err RuntimeError) Error() string {
 // TraceFrame is tracing information about a single frame of the call stack.
 // TODO(sbarzowski) the difference from TraceElement. Do we even need this?
 type TraceFrame struct {
-	Loc  ast.LocationRange
+	// Loc is the code being evaluated in this frame. Frames which don't
+	// correspond to code (e.g. manifestation) only have a message.
+	Loc ast.LocationRange
+	// Name describes the context, e.g. "function <anonymous>".
 	Name string
 }
 
@@ -26,18 +26,119 @@ import (
 type analysisState struct {
 	err      error
 	freeVars ast.IdentifierSet
+	opts     *analysisOptions
+}
+
+// analysisOptions enable the optional checks of the static analysis.
+type analysisOptions struct {
+	// Records the use of local binds, to warn about the unused ones.
+	usage *localUsage
+	// Reject obvious type errors, see staticTypeError.
+	checkTypes bool
+	// If set, the locations of all nodes are added, to recognize this code
+	// during evaluation.
+	locations map[*ast.LocationRange]bool
+}
+
+// localUsage records whether local binds are referenced, to warn about the
+// unused ones. The desugarer copies object locals into every field, but the
+// copies share the binds, so binds are identified by their address and one
+// is used if any of its copies is.
+type localUsage struct {
+	used  map[*ast.LocalBind]bool
+	binds []*ast.LocalBind
+}
+
+func (u *localUsage) record(bind *ast.LocalBind, used bool) {
+	if _, seen := u.used[bind]; !seen {
+		u.binds = append(u.binds, bind)
+	}
+	u.used[bind] = u.used[bind] || used
+}
+
+func (u *localUsage) warnings() []Warning {
+	var warnings []Warning
+	for _, bind := range u.binds {
+		// $ is introduced by the desugarer for every outermost object.
+		if u.used[bind] || bind.Variable == "$" {
+			continue
+		}
+		warnings = append(warnings, Warning{
+			Loc: *bind.Body.Loc(),
+			Msg: fmt.Sprintf("Unused local variable: %v", bind.Variable),
+		})
+	}
+	return warnings
+}
+
+func containsIdentifier(ids ast.Identifiers, id ast.Identifier) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+	return false
 }
 
 func visitNext(a ast.Node, inObject bool, vars ast.IdentifierSet, state *analysisState) {
 	if state.err != nil {
 		return
 	}
-	state.err = analyzeVisit(a, inObject, vars)
+	state.err = analyzeVisit(a, inObject, vars, state.opts)
 	state.freeVars.Append(a.FreeVariables())
 }
 
-func analyzeVisit(a ast.Node, inObject bool, vars ast.IdentifierSet) error {
-	s := &analysisState{freeVars: ast.NewIdentifierSet()}
+// literalTypeName returns the type of a, if it is a literal.
+func literalTypeName(a ast.Node) (string, bool) {
+	switch a.(type) {
+	case *ast.LiteralNull:
+		return "null", true
+	case *ast.LiteralBoolean:
+		return "boolean", true
+	case *ast.LiteralNumber:
+		return "number", true
+	case *ast.LiteralString:
+		return "string", true
+	case *ast.Array:
+		return "array", true
+	case *ast.DesugaredObject:
+		return "object", true
+	}
+	return "", false
+}
+
+// staticTypeError reports calling or indexing a literal which doesn't allow
+// it, e.g. 1(2) or null.x, which would fail if it were evaluated. Only
+// literals are checked, so no types are inferred, but the expression is
+// rejected even if it is never evaluated, e.g. in if false then 1(2) else 3
+// or in an unused local.
+func staticTypeError(a ast.Node) error {
+	switch a := a.(type) {
+	case *ast.Apply:
+		if typeName, ok := literalTypeName(a.Target); ok {
+			return parser.MakeStaticError(fmt.Sprintf("Unexpected type %s, expected function", typeName), *a.Loc())
+		}
+	case *ast.Index:
+		typeName, ok := literalTypeName(a.Target)
+		if ok && typeName != "string" && typeName != "array" && typeName != "object" {
+			return parser.MakeStaticError(fmt.Sprintf("Value non indexable: %s", typeName), *a.Loc())
+		}
+	}
+	return nil
+}
+
+func analyzeVisit(a ast.Node, inObject bool, vars ast.IdentifierSet, opts *analysisOptions) error {
+	s := &analysisState{freeVars: ast.NewIdentifierSet(), opts: opts}
+
+	if opts.checkTypes {
+		if err := staticTypeError(a); err != nil {
+			return err
+		}
+	}
+
+	if opts.locations != nil {
+		opts.locations[a.Loc()] = true
+	}
 
 	// TODO(sbarzowski) Test somehow that we're visiting all the nodes
 	switch a := a.(type) {
@@ -100,6 +201,19 @@ func analyzeVisit(a ast.Node, inObject bool, vars ast.IdentifierSet) error {
 		}
 		visitNext(a.Body, inObject, newVars, s)
 
+		// A bind is used if it is referenced in the body or in any of the
+		// binds, including itself, so mutually recursive binds count as used.
+		if opts.usage != nil && s.err == nil {
+			for i := range a.Binds {
+				bind := &a.Binds[i]
+				used := containsIdentifier(a.Body.FreeVariables(), bind.Variable)
+				for _, other := range a.Binds {
+					used = used || containsIdentifier(other.Body.FreeVariables(), bind.Variable)
+				}
+				opts.usage.record(bind, used)
+			}
+		}
+
 		// Any usage of newly created variables inside are considered free
 		// but they are not here or outside
 		for _, bind := range a.Binds {
@@ -141,5 +255,15 @@ func analyzeVisit(a ast.Node, inObject bool, vars ast.IdentifierSet) error {
 }
 
 func analyze(node ast.Node) error {
-	return analyzeVisit(node, false, ast.NewIdentifierSet("std"))
+	return analyzeWithOptions(node, &analysisOptions{})
+}
+
+// analyzeWithOptions is like analyze, but also runs the optional checks
+// enabled in opts.
+func analyzeWithOptions(node ast.Node, opts *analysisOptions) error {
+	return analyzeVisit(node, false, ast.NewIdentifierSet("std"), opts)
+}
+
+func newLocalUsage() *localUsage {
+	return &localUsage{used: make(map[*ast.LocalBind]bool)}
 }
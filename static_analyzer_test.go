@@ -17,6 +17,8 @@ limitations under the License.
 package jsonnet
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/google/go-jsonnet/ast"
@@ -73,3 +75,78 @@ func TestSimpleLocal(t *testing.T) {
 		t.Errorf("Unexpected free variables %+v in local body. Expected %+v.", returned, expectedVars)
 	}
 }
+
+func TestUnusedLocals(t *testing.T) {
+	tests := []struct {
+		snippet  string
+		expected []string
+	}{
+		{`local x = 1; x`, nil},
+		{`local x = 1; 2`, []string{"x"}},
+		{`local x = 1, y = 2; y`, []string{"x"}},
+		{`local f(n) = if n == 0 then 0 else f(n - 1); 2`, nil},
+		{`local a = b, b = a; 2`, nil},
+		{`local a = 1; local b = a; 2`, []string{"b"}},
+		{`local x = 1; function(x) x`, []string{"x"}},
+		{`local x = 1; { a: x }`, nil},
+		{`{ local x = 1, a: 1, b: x }`, nil},
+		{`{ local x = 1, local y = 2, a: x, b: x }`, []string{"y"}},
+		{`{ a: $.b, b: 1 }`, nil},
+		{`[local x = 1; y for y in [1, 2]]`, []string{"x"}},
+	}
+	for _, test := range tests {
+		node, err := desugaredSnippet("snippet", test.snippet)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.snippet, err)
+		}
+		usage := newLocalUsage()
+		err = analyzeWithOptions(node, &analysisOptions{usage: usage})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.snippet, err)
+		}
+		var unused []string
+		for _, warning := range usage.warnings() {
+			unused = append(unused, strings.TrimPrefix(warning.Msg, "Unused local variable: "))
+		}
+		if !reflect.DeepEqual(unused, test.expected) {
+			t.Errorf("%s: expected unused %v, got %v", test.snippet, test.expected, unused)
+		}
+	}
+}
+
+func TestStaticTypeErrors(t *testing.T) {
+	tests := []struct {
+		snippet  string
+		expected string
+	}{
+		{`1(2)`, "snippet:1:1-5 Unexpected type number, expected function"},
+		{`local x = 1; (1)(2) + x`, "snippet:1:14-20 Unexpected type number, expected function"},
+		{`"f"(1)`, "snippet:1:2-7 Unexpected type string, expected function"},
+		{`[1](0)`, "snippet:1:1-7 Unexpected type array, expected function"},
+		{`{ a: null.x }`, "snippet:1:6-12 Value non indexable: null"},
+		{`true[0]`, "snippet:1:1-8 Value non indexable: boolean"},
+		// Code which would never be evaluated is rejected as well.
+		{`if false then 1(2) else 3`, "snippet:1:15-19 Unexpected type number, expected function"},
+		{`local f() = null.x; 1`, "snippet:1:13-19 Value non indexable: null"},
+		// Only literals are checked, never values computed at runtime.
+		{`local f = 1; if false then f(2) else 3`, ""},
+		{`local f(x) = x; f(1)`, ""},
+		{`(function(x) x)(1)`, ""},
+		{`local n = null; if n != null then n.x else 1`, ""},
+		{`"abc"[0] + [1, 2][1] + { a: 1 }.a`, ""},
+	}
+	for _, test := range tests {
+		node, err := desugaredSnippet("snippet", test.snippet)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.snippet, err)
+		}
+		err = analyzeWithOptions(node, &analysisOptions{checkTypes: true})
+		var msg string
+		if err != nil {
+			msg = err.Error()
+		}
+		if msg != test.expected {
+			t.Errorf("%s: expected error %q, got %q", test.snippet, test.expected, msg)
+		}
+	}
+}
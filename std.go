@@ -192,141 +192,140 @@ var _escData = map[string]*_escFile{
 
 	"/std/std.jsonnet": {
 		local:   "std/std.jsonnet",
-		size:    41755,
+		size:    39079,
 		modtime: 1502146172,
 		compressed: `
-H4sIAAAAAAAA/+x9/XPbNrbo7/4rTvnWqRTTsqwk3taNM5Mm6W72tsneJt3dPlmjgUhQgk2BLADJcpv8
-72/wwW+ApOzk7ebO1XRSWQTOOTjfAA7Ak4cHL5L0lpHlSsBkfPoE/pIkyxjDaxqM4Hkcg3rEgWGO2RaH
-o4ODH0mAKcchbGiIGYgVhucpClYYzBMf/oEZJwmFyWgMA9nAM4+84XcHt8kG1ugWaCJgwzGIFeEQkRgD
-3gU4FUAoBMk6jQmiAYYbIlYKiQExOvjVAEgWAhEKCIIkvYUkKrcCJA4OAABWQqTnJyc3NzcjpKgcJWx5
-EutW/OTH1y9evXn36ngyGh8c/EJjzOVYf9sQhkNY3AJK05gEaBFjiNENJAzQkmEcgkgknTeMCEKXPvAk
-EjeI4YOQcMHIYiMqDMqoIhzKDRIKiIL3/B28fufB98/fvX7nH/zz9fu/vv3lPfzz+c8/P3/z/vWrd/D2
-Z3jx9s3L1+9fv33zDt7+AM/f/Ar/9frNSx8wESvMAO9SJmlPGBDJOimpdxhXkEeJJoanOCARCSBGdLlB
-SwzLZIsZJXQJKWZrwqXwOCAaHsRkTQQS6u/GcEYHD08ODk4ewnspQsLVs7/xhFIsgAtEQ8RCiMmCIXbr
-AxIQY8SFapYiJrgUGpF/IwGIYcVOgankrAEzOoCHByAxYIZVG56sMVAkyBbDGotVEnJAHG5wHPtwsyLB
-SjULcUQoDiUoiY5QgVnKsMBMjgtQGGohSu2TCKQCjgBeCzkOireYAcUB5hyxWyXsdZowOapwdKVJ8yXp
-hANeL7CCRqiCV0MmJHSpzyTGx4Kssca/EckaCRKgOL41wDMQKI4hUVLNeJmyZMnQmktunBz8oTU7TgIU
-S4LgAjiOI1//LJJ3ghG6HKDh+bn6RX5IpEgXtykeoCFcXIDHVTNPUiyNCMccg+fBESADiW8WXLABF8yH
-iCVrH2JMXUC5YEP4qgY2byk/mLGEgaehQkQYF1IL0Frxia+STRzCAgMCDcKHZSJAElRBksNUBJdJkDRq
-GuhmvcCskwaOg4SGDiI0DAsRCo2bCsmjfYgQK8L2pkEiaZAQYwpPYXx3hEuGkVAmjij8jllSYI5LICW+
-CnxlFAmhA8/z1R9rdI2fM4ZuJaE+RBsaSBcyIEMp2ymBI6VQs+EwUzUh3cE/iVgNkA8Li5LFmC7l0yE8
-Lf+9GDaHG6EygVZqjWojH8Z+FZyyjYUhC9Pw30JUFfZxFXYbwdpyXqwQ48pYSiRX5VICIdtZZDTLZJMi
-xvFrKuoAtf9BYfiSLIkYoOWS4SUS2IdQ/jCEi8oISaR/Vyr64YP54xl82+RVobMDL8OuNFEPz3j5MMFc
-JRFrJIIVMLzEO5iOj7+dHXnDqv7XuS0/p2N4CDnRcKQJ+q42PJGo0Wlu1kY0lUwMkhCnCaFiEKwQy4RV
-/OqNvaGKvPKxjEZK0jUxzb6rahabjmfKRx9b3MexhBAlcRgPMub7FTqnp+ezoQ/jYbu6tYFQ3TOdSmMi
-dBQI7u/95eglwM8RAIJ9aWgNAE4iAjsJxpw0Eaf3wL5CWwwaGpxW8ec4OjyJhP+jTN2M3Hw4Pq3Is/pw
-jXbqV/7p5KsQ/CcIWRPyb5V0Owl7i7vCjkJ0/ZKOKk13Sj4KlG4tNMFhs9M6FuKYrH0gPiDGfNjWXWnR
-JZDZrIo/3zlakAlcgEwhTpstSAQEnl1ALbzZQ4z8IMbgCKbbWTMAGU4H0hEr+uHBAygGL38+PpWRrByt
-GZO5QEkobsx13kz8ghofPG8IApFY6mYgrNTtA9aHLRxBUIZZ5V7eL1A50VSTYFwGQ3SpE18ZJtxZhUjg
-WGV2UjrVnCLL+XIvJKfgA0JDvJOzax/UV1+mXDLBwWkz1SB0i1hNcU5OIE6SVD8jiAq9XhDiCG1iwfX8
-HYeVPn801SYj47z46ttbnVu5LvVOPpVKQTdxrCdVY2tbrVhqtE2x0tCJANOwCr6kdznVQzdKydnGU8lp
-J0b5sIry1A1eNm7C1/Sd22ltNpf+5bzwNK6mHyv5kpL9SPPfJJf6J8mxyg9qQC1TpIEnnd30kJ+r/2aw
-2AigMkEkW1zRUDmHl9C4WmyQWSjfpHoS79l4dAjTEpl+QaBfIm1m8/NaBB0kH3JFqmq93nDRmM2NPTjU
-ZuUOJQW3KxH2wQNnEySt3hlsBjraSEMHFAQ4lfaY5fAJA0RBAfAV7ctEnMMh13Q20HWGmsWGxOFAIfMh
-2DSSdaMrwYbJ+FDox4cP1d9MIHa6bYWhvzvWZFkfQVl75XAbqzLObjkhcFTWfsmpabBhzWDWSWcd6tQG
-dtY02OwjeXhUUmVrQ3f00Wxq5wZ4ntZZGZtK5GURJUg2VAxUrNsNz8+rOUA4ikgsMBvkMWk7hK3EsVPx
-MV+JWCeha7ZfXjzL8qyycSyqjxoSVLEyCTdxojG4LdGyQmeZvrE1EhZItrTvbYoZEgmDQwgQle5qgWHD
-9RK0RMmrKR4awhF4ystVfl+o30dexiyUKoZqFjo4Jhtod5HxvsNjrFFanjjkDi3rbluTkzjaGMpYxWOV
-xZY/65hSFMSVM/mcOgUZTtyTCaVl7fM26/qM7FfNpeR3+fOUFCtoav2N47QhiiITV6ZBfM1bH9iGUkKX
-lnWaegqtOGR1SAZGY7VFQtEEVvKHRv+CLJMx1khzZMAZCq0l/SCjmOMcMhwZ8lpR7A+U49QK+ju7L6lp
-pWvSJmXbOoPVUdSqcW6b4DjtcDP5cMc+CLbBakrQA6BrOE1401m371Kjdy8klPIJBxskXcZKYkIxH9Qs
-pFi+vqRePgnzvHwF1LhaNT3aolitkuS9T9o+pVbwd8S43pBbE0qO8823Sqs2WPWlSXY7V6uj8zVKU0KX
-82t8q4kkPYzaPS82fH/PNjRAAodm/BAkIR553VbSPZHPptXeoCXRMWASyjdrrMd15Vg6KMG92mv6f4ch
-dw7fzYorCytq5AfKHwx75H+gUp4yc7RP0vP89tF2kS0/fwA5z2Gew7Y86XLSYPyi5zUJcCJViIhCogLF
-x+/cmh5EMVpyh5LvoTB7K8qeCuIcbD+FyO3j/3Qogl0B/gAUi3PlYuFjy4pAjmZ8RzS/Y5bsg8e2ndEH
-T4yjvcYDd8SziBG93gfR0R0RcbKk3XicYLVt2u2yao9+pgwmVdESM39otpo/zNjNX5pA9Qd8HLZYZERw
-HM5vSKhNyBV7njZMTaXgWYboPXQwUjuH3AvJdh97R6C+oeNOYeMOIaNVpv1DRW+zBacGPoTTMRyVtwht
-tBaYTu+L6bQvpsl9MU36Ynp0X0yP+mJ6fF9Mj/tienJfTE/6Yjq7L6azvpj+fF9Mf+6L6Zv7YvqmL6Zv
-74vp23ZMrYDbokcDMfFh3Ob/U4YDwklCv7CZx6hFAm3Rze7L7pTcnpzA6yVNGA59xSYBeEe44CMnszUD
-5+skJBHB7Atj+cpTq+3qe1z6/mOLKBS/+7ObNDTVzBsSup1nxQVfEMvCEptI6fumhWX1lCn0fAhQyvN0
-zsrOAmeyB+xkT9i7PWDv9oT9rzvB1jl4B2i8B2i8J9mv7gS7F9nRHqCjPcn+4U6we5HdtvtVB73ck+y/
-3Al2L7KDPUAHe5LN94DN94R9uAfsw16w21ZQfqEMB8mSEo5DmWlszVERvfPvwREE3x0cNNZNQyCUCIJi
-OPSBJjdqHZVhLurBMvP34X+Qq19f41u4aF+wdVU76RWvSu/yIpgEPXL3jm4qPRvpjAbVAkCmdxUQtXwv
-umnpHGMqc5VKf2sKI4G2wAn0frA9lhskls7NOh/5Ieca3ojYd7OlaM8dfeVHcvxc833bsh+uGHueMbil
-ZXRzLrnY0kJy51zzqA2jtiA9trZ2ynx1M/nd3vJj8+da6qq3M1BmEWYbZhAkVCCitsNoQjEkDNYJw3CY
-NRRoyYduq+X5tCPZCGslx14GnGwEHEGzKOK+iVmb09RgWElhw/LyuEVTISuzL7OAjTQTDP3yB/n0HrWB
-TS5rz55xulkhWBOT6loZlwFUVAxWnXfnptgPRilWGLYo3mDeYzusooa/cBxtYtgIEhNBMG8oVhgSuhzc
-+MDt+wVos5NPXdsEN/DUWn2Vfbb92a8wwXG2ScOHzQrMm4yHpRE+D0PgYI6WxTgSkKgzAsATfXKOCJ7V
-DxNenLK7aRpZOJf9tchsHMm5VT1zog8E8KHaVGUt1OlzpPcgTwFw0yfBHnVRWaXvZ6xOLSKqTuUtMYMB
-Hi1HPoQ4IGsUSweVBALFDZ/EVM85oWJA53Mf1oTOgxViXH9Vx0W4WTfXC+Y+MBSSnV5ll5EyIju70tE5
-aAeGFlxCr6lCoZnUoZbUURSYfUoU7KegqrQoThI2oHCixzOUgh9QODR/2mgNVZpgqgFM//mwIFItVqu8
-s4ZkPrTCo3gJF0Dnc3gKY1uD31O4KEQCxzCQXMFLOUdWIlGV2WRJTeGqRj62Ivs9nRhxrNFu8HtaFrBr
-tBPlBo1BhTjwJRRfDrPWI6NLc+HYK+omcuK8o+JXTbv+GbzsVKaUgETapduEwgrvkNFth0av8K6/RqMw
-nEtl2qnEnwgUO/wo3awxQ7EMD9OxL53cxIdHPjz24YkPZz782YdvfPjWXZyoPkcqxhpMmg9T77nng/e9
-/OeF/Oel/OeV/OcHrwOcLhj0kGy8kP/ImZdaElGTaS/yZlb1+9z26e2xZ9w0y9MzZZMZy6fSNk/PrCNZ
-4d2XYZguOWoAuRrqXpNPas4rvJM9LHgGDWX0xv/KrHK884a5gbrV8KjwEyu8+/x+Qo6mkbnJhC6dC4ZI
-LCeecoi2I421YzuNyTMU+7mfSLnNeUezDdy1o1lQJvOoO+ycl87ZZomrLSnP8dQnGceqqcUJR3GChLpB
-ISFU3WGRJRhy3mN3xKrPPMSBdsc6ZqOw5oEx5RuG56nwIZOfnjHfI7W4WSUxNu1ye7dGukTMOfkdax+i
-VwOk63jwAL7KCTOnXbQSnjqdQjY+ONaAjnPoti4y17uoJWFwIof3UJMvLUkJsMKt07FJocsQK6RbFUxn
-luqUTWVQ3ii3rb7zx4ihoMLaAZ3DsaZ5CA/VgzS5GUhKtRiPYDx6YpkWkiiXuHSaCvCzNsMrCJg32Cd/
-1QgV00ydh/mflWtV3khOKA59ldNkTiE1PUtGgXGP2Z/9vUJjmuG0Mh4QTIW6zqTL0HhA9jc0tUTSYm54
-lyYUU1GRuPIayXJQNsOhqg3Xv5+O7cGVb6LIBCKJ16jgq0wFcXuYKQk7o0plYIW0ddGtVdhmpRJRQThH
-JsSfVJQ1A9oSdEsmXvacmyiqdWp4wAyziY99PeCR4pk9LdYrCvpAoDprtCCCIXZbWcStKYx+pJdstij2
-VSMfohuNcJ6wOd3Esbv6MFuuVcD1XzZ2RalZWTWuKQMMX5WP21WeKB04swEjd4PlTt+k/umFSylQ5ezN
-32r9QcGLbqxQZFc19OIMjyOmq/rs7LKaLYqbdzZYgIUOYOU6dAmr/TB0jkQv75tFqPzeJ90PkAB36a+a
-qUi3aCk4bwwG2nxdyWyVxsmoRrSfNkw3xmD+qsS5Pjxzbeh+wTwzulosclSUFsWiNrfxPHtouxvvv6mu
-8PSRgWvj+wuWQWk1YQ/eFSJyb1GYj2YhSnkvDrs2u798DhdBMudzhcFdfDTcdEjBJAU6IPXitKsa4n8K
-p2We+Bk5nav1Xkx3FUV8wUzfI4GWgNsT6BI/cohP4fixnDjlPzy7yBKv1lWGntpwZ8dWfMra0dCLOy5x
-mIm7Wu6aL3CU6OlssSJ2WqTyLfuRDUa4HdAnZkQ++voYehmKqwynYSitZ7Wzj7rMa4WYW7XtgDuP8Vev
-Esr6WS4TKn+2KN5fH4ytHwZS6jgQOITTY05+x2G2aa+uXqjdObSfKVtwGF9SORB93uEurPDzwqFrmtxQ
-U5+hCoVywTvmf6muHKpWKBTTQYbUrbF637llIsjniLGB+mbu0iH28ySWygTVy1GboI6dPO130BpKx9Df
-J4mcqN9mO+YiMdQ2hKjgKVedi0W2uNpDstu+y15ypGbey121E8XFWkmIexpKuyB0AcOVOfaooN6lMsIU
-Wq+zya/UrOimfDKppSLoKjsn6WwR3ZzbDhm1izsnWuv/m0QApslmueond7c/6DofgBibXjnu+viofV47
-M9oZYZjrgG8PRrl8JiUBZUu6/UQk1umoQ0y60IpEpu0XIyxF7j0Epvp3sSVn+R0EdyXFJqU3umprtkVx
-x+HuyT7+MvsofZ7c41KcbqFar+2T1LUNl7cPtpbXtFWcZR/v8B5n1R1Ln1I5ohtfi09P768m7eOap/p6
-7R7Dq60qthJYKhAqyPKgZVG8c8xFSVQVYqsyP/rkcrua3H0IVxP7VYjQN3Y+MuVoRmqOAsDeeVWyuMKB
-6JlYJYurjKZkcaUSq/tmVf9ZCUtjgKVLGu6ZrUQ91FBXnrfdwFNBqP3cT7o6Ha7xbfEyhPvcgpGT0mZW
-Mt3qHE81J+szmBf6uqsNx+qSfxyHoOrftb5qZb332KKbtpHpPKVzbPV0Zu/R5aX5n3RsEuq9gra0IU3L
-XxF/HscDZQhRj8CdLK6m0aeI28A3wUpLX6df0Zcfl7MtScnKf3s0/rSRuD0K9/WvHQGttmzD26+uckVS
-2VPtsI87LscqUGhb6MJRGliOo46gF4VT2X1WojG/1CoOWXF7n68OWNkvjis1Mled+UDCRmmzDNThzl0a
-5rosrtGwhrO47i7czYrL4RQNao3UetFaDYgcnd+YO8jeJX7En4Mf971Lrxd7cjoyPhn2HO3FHnXnv+aG
-ui3zJ5QO9Le5brtG6bz7wseix173PuY49779sYSw9ar09D5EWW99bKEqR7fHvZQ9SSld2169f/Ku106m
-g0Ky5ctSy7Iv35KKOMdMvPptg2LbbalIvZOkORrBNi3vQDHh+rmCLdOYCJEYhyM1HqQuJJWsgqP8dSeq
-tsqlhn1fw6O3mOoL1xZO0hY2qpLvrDYPqJb0Mc3vSd11XSrbl9Q12lXMo5ts1KJ/i/0QV0ygG3PbxbRK
-S54ZJcleP5XJdU3o//LLwq+nDn5FMRICU3V1rLrektfvtyyim7pEVjFX2dRCGTZXV3Hm2kpJhLl4TcmA
-UNKMgYskvJ3rqzTl1yFcwNQ75HBh7u+eXvuqzfR6NlPvvrnOXnyjc58fZCJu+jaWLTlWJBr4nKI19oEX
-eKaHfKaQqEezmRxDQY9uWIe5RoTO5ZPiKEQ+I5FD9MGTTTwdmcvwCCUj+chUsk4b5KI4nhuS1dGXKvnX
-KrKOsgbT61n7zYzQwq4yoPIbg2rXlxZjPTIb6hXdKJE7rF1zinmAUqwL8v7G9QnveVP6urK4Ur2nGjZe
-ncQQ5YNgZUmLgpXKhC89x9zJu7y8tBRcl7tetnS9bO+6cHddtPd01Rh5l5dRe09bmmF60vaert1p7/KS
-tfe0zTFMT9H/mprUCLv8oit7rXqQwlN4NIEPH2AQpDLvPZ2cwYMH6sEFnD75tmXO711ebg7Hj3fKtIPU
-/j4Wa89gVVDjXXqHXKrVYcksPB+mhTLqd3E538RlM4a/34qVNoe6W7WZjA3C94ivPrs5fe2S99eX6r8e
-Mq/w8utD/vUn5uTLJI5Ng8/Kij+5WPGnP+3JhdbgqQnJXslX50B5tp3FVKUkamE8e0VB+cmrnX7mg6eo
-tfUu2hAaYuqYom59SJFY+RCYVk1WqbcfqJtt7KySj+wORvXUF8DYu6pnLX3dy9GefGTvWSyl9KjdUS8y
-bW4F2OC0LuhbjXxrL0SqA26ZYUL5bhmi30SbCTmfU6o6PHV8UKx6IXRNIqE4D4PoMjv3pV/qVH3/5TY7
-4WbvTvHNXOsTXGSapV77Ib84b4GRmZBO3S5p13ldk7QodzP1fNlhjyqy6bRE4ZE2hCmZaVtQbzWZ+aVB
-DLuIKX+ktyPS2Sm+deZxMrWSUf+oxCdvVj9tDPW3pypm9VMv5zoiWFj/x+dnfV0f5IAvlR1e66/nrVWl
-FnK0/K5L8pPf63j2lqI9vd4O7ybTjz1lWl16My68+pq1zAP8itbxO8EwWuehwjoN1g/7vEGiCRkSGt+C
-QNeY66Ujbq/n3eCWSal3fHysGVKehOgfff1+1ErgwzppwFIACvZMzkG+vqSj0eiSfp0tPmZ9TN6VuMaf
-dBiC2VhU8jVz1OwVU9O6Yze4rod+JSpnJMiZW7ueuTUrGdY0xPvjkH/MqdBs88HzDaklRA3bT9o9fWl2
-XIU8tQ1qosWRTCTZyawHXmes9NrZmvSA3bkg65lXFzUCpdcJu/WdTHmymTRXfhJ3kuS9ryRIlR6O5Mj7
-oZoYVfrYkyLvTUKx59sM4x8y0QwSGjVTwC1i3LYqY9ECCUBpd4sGKyTuJQeFrVhLOCjoWCCOzx7PBVqo
-4+be8+9fvHz1w1/++vpv//XjT2/e/v2/f373/pd//PNfv/5ftAhCHC1X5Oo6XtMk/Y1xsdne7G5/H59O
-Hj1+cvbnb749OvH8JnBCt3ABf8C0jGxKZrNzIEXUrmQ8Z4+G8NHwVPcaEJpuLLn04lbg+n5w9Z15slu/
-PDJ7W5aaRATD+qx6KDN6Ca17gqLaNe54KL/jqc+taS1bUdYERO2t9oZRmtJZ/ebJCZzBT+++hyQCYm1R
-kad53xU8gMmTyRCePYPJDI5ckCfw4x0gPxrC06fw2AXXu7iwHCOsvBPqkQ9M35LV+e4q2XzypXHTh8c5
-liP7y0DbeQsfQP8mdUnhfzxW+J1cPzmBxzn+/jgz+KdPFGLn8LxPJdP/FVkZf1lkPkwKCuyVjj0EWCZj
-oh58q9lw1kLGWUHGPogV/LNH9bwN+qlG8/IdRIm4zQ64VReTmA9oCEy9WxrBU5g8ORv6OuzoA4PDyrvj
-vjKwXCnSC0R1Zq8HBpiqMkcdmzicmFxf3dFH6DLGGtXIcyf5csSGnryApBQ5X2KJ4XvZoL5CWj3jpLh0
-CI/lfMX5OltVZI2q1OfnlMqLu8xNcP0SI0s0hLu8K3yP+0tOTgDFMZzBgghuzHDSZYZmjeVUJm5FbjPV
-1yPNciOoP1KGMlMGaVNYsBnk4w57NKRMuir7cnORCdCFOYYxbS/Zg6lrCLmxP3aMc6LHOWkZ56Rk8X5d
-CEeP2kbbUdedEfHoE4x2Misc7Bl8AEuTR7OZY5SlS7CO4LF2QfRU/jOR/zzq8ZL3cXm/t2zHdROuZMHK
-VOxWb5sbeH4z7V0MiyOVi8zR5WUlJyfw3xsSXPOE6QArv9Rf12iWtqCROTVeslnOquxXP01nXW4kJdtE
-wIWqqxpbb9hjciqana7N3t8a63tey29tLaKa9QIdVXV5Uam+yTrvhrCDpxeaFl8htILQ16+2wHjWAkLt
-YEh2S0LU1rBqOzOLO+qRQpAJa0PJb3bZRGaLxDptyoTiEokSy6J5W7OqgJhWIRzLFMFeaiQ/qDtJQ3Kg
-i5l1v8eUxhWGwnFDGWVzxYicR+UyKY7FT3i9wGywa1TMnZzA+7cv3w7CQJXuDc/he0IRu4VglaRq3vp2
-ECdLoEMIknUa4x0RtxW8pZdbcyxeUynv6W5mKrXgGYwLMn6h+c5VjXo1KDiCRYloDaveujTL9GGRnYJF
-QeNmLNtccwgfPjQOQC4c0RYFQY9JsNQHfW/gYno1c8ftEsXmyIv+HwoCKX0JxP0eYKioH5nB072xGR7d
-5aRHldk52e6STt14nHn4UrtCui9JFH1q4fYWo+0crFMN7KzsFtpn05fPrib9FfL/k76sMVvivyMRrAYC
-sSUWajMmWLnW5PXDPuvyGtzcnBez5uc5VN22CdYAMWdcy28oaODJtgF64DE0WdA1VkZrHUyRmJUOuonj
-ebEZcd2y3mqYSCLN6+l1/hp1awaySMSqgGycuvb4lcH7LkzDGsHN08LT69m5VePUnLRST6dA+nDdccCn
-wrnptT2LzkyqhqLStxuVSs0KPdYX+GWcbTU06Dq6UgNdH1RPNBVNyDx0Sap+WXmq68Mf3QmsFoSWbG1D
-qpYDlJ++2g0Sc2Hk0NJZHeDq7q9WLirdpdwkYHvfv6IMsRV3dnKsV/8ybvzbBsXcEe0EysqNdN1vo85o
-UX6+qK/EiHCUMrImgmzxK41HIB+ELZypMblFZXyQDZzZ62tdF45RbTZkL9CzkhwjvxqLW4ypOgrnaKrE
-lWJSx7vbVV4Ro+6zZ5VzBDZy8jD8lQz2pCUOtw+tDLO1ey2cu6fgzQ5jd7mHVR9MQGpViEowqNi+TTWM
-ElU6GXUwLseqTqb9VxYsrozOzelPrESGtk+jSfl5azPkKZl9B2gaGfWKvjT1avSw6ZpymdlSL8M8ibcy
-yK3kvNgy50csq93kaUyEbOWdeNZ1oZN8YShbLakv1tjXTlSxyJTl0/GUbah02w1aCH+RUIGpGDQWIYxj
-d/l10Dq0aC9SbAoz0xTRWYdXnfY8g3ELnNYKr25AjS5S2RsxzhUCJRVtg5karZEy2OlCkp1MYZAywlwC
-f8qbDKFZA9IcZy33nO5m55DBQNNdrUYrx2rxc1U6CmIVlALMR8uOh3/w8eD/BQAA//+qVt5kG6MAAA==
+H4sIAAAAAAAC/+09/XPbRq6/66/Y8tWtFNOybCdp68SZcb6uvmuSXpO016doNBS5kmhTpEpSltxc/vcD
+sMvv5ZKS3ddL52XuXEncBbAAFsDuYsHDe51nwfImdGfzmB0Pjh6wvwXBzOPswrf77NzzGD2KWMgjHl5z
+p9/p/ODa3I+4w1a+w0MWzzk7X1o2/Ec+MdnPPIzcwGfH/QHrYgNDPjJ6jzo3wYotrBvmBzFbRRwAuBGb
+uoCUb2y+jJnrMztYLD3X8m3O1m48JyQSRL/zqwQQTGIL2lrQegnfpvlWzIo7HQb/5nG8PD08XK/XfYuo
+7Afh7NATraLDHy6evXj99sUBUNrpvPc9HuFYf1u5IQxwcsOsJdBhWxOgzrPWLAiZNQs5PIsDpHMdurHr
+z0wWBdN4bYW847hRHLqTVVxgUEIVjDTfAFhk+cw4f8su3hrs6fnbi7dm55eLd9+/ef+O/XL+00/nr99d
+vHjL3vzEnr15/fzi3cWb1/DtJTt//Sv7x8Xr5ybjwB5AwjfLEGkHAl1kHUrqLecF5NNAEBMtue1OXRtG
+5M9W1oyzWXDNQx8GwpY8XLgRCi8C0pyO5y7c2Irpe2U4/c69w07n8B57hyKE/+Gzv0eB7/OYRTH0t0KH
+ee4ktMIbE0TCPG5FMTVbWiGoFQjNxe/wCJhH7Iy5j5yVYPoddg/+Bxg4PMc2UbDgzAeSrjlb8HgeOEBp
+xNbc80y2nrv2nJo5fOr6wGIAhehcP+YhsAj+4riY5ThCiKh9iAAVsM/YRYzj8DnwA/7awFIgnYS9WAYh
+jsrpXwrSTCQdGvPFhBM0wBFUkcUIHfUZEBzELhBP+FdxsIBB2Jbn3UjgCQj4iQUk1YSXyzCYhdYiQm4c
+dj4KzfYC6IwEsTMWcW9qip/j4C3olz/rWr3TU/oF/7lTIj2+WXJ4wM7OmBFRMwMpxknEPVARw2D7zJKQ
+otUE2nTh/yabhsHCBPH5dUChVY99UQKbtsR/PAxBAQ0BFfgdgiaAFlgL4lM0D1YeTDlgDxMgTFDLmCFB
+BSQpTCI4TwLSKGjwVyCTsJGGiNsByEJNhIChIILQ1FOBPNqGCFDAcGsaEEmFBPiRPWaD3RGCZbNimuJg
+lX7nYZBh9nIgEV8BPk2KwPW7hmHSl4V1xc/D0LpBQkF5Vr6NJqTr9lC2QxcAIhdHvV6iajGag1/AlnUt
+k00USgaAZvi0B0PMfZ/0qsOdWnkCldRK1QZcA7MIjubGRJLFfedPIaoI+6AIW0ewmDnP5lYY0WTJkVyU
+Sw4EtlPIaJTIBjQl4hd+XAYo7A8Y0ufuzI271gzUZwb6Y4KLgx+ArsIIgWX0O6nov/8tvzxh31V5lels
+10iwkyaK4Ukr7wQ8oiAC7Ch8BeR8w4aDg+9G+0avqP9lbuO/owHY5ZRo0Egi6FFpeHFAoxPcLI1oiEy0
+A4cvQffjrg1cT4SV/WoMjB55XnyM3ogkXRLT6FFRs8LhYEQ2+kBhPg4QwjTwHK+bMN8s0Dk8OgXhsUFP
+r246ENRdyt8OVjAOKwQ7tAH5s4LqABjXA5vRTfXnuseukfYNOPwwTGf4wlpSG/FrjRPBBsJ4JuBqzWfX
+EAq9zHsStljBR7BkSXeV+UYcGvuN5BEJFk4Ug331leJZg5PLiMu7mJQ6gswO6/0cMU4vPeVUxn7FqYyf
+8Wecz4koyFRHfFkRhZzRq42QtmsK3posXPkYHSqmtMuenLESBeoJLWFUJiZCEQSi1vgrCH6U/TOygFNH
+VdJgLeAhQ+1YiUJoSTvIaKpTyBgQCf7pUGwPFASgBP1IHbOVtLLOx6NstXENdq/RuPo5AbRWY8aOcrjg
+T+NwBSM1jDYA64ZThTccaWZEfvT1kaV0H7iIq2ED0iVniQfLh6hbmiFZpPPBN2j+QO+hYaTOEiw9uCMR
+M1+DyLFz2vtQ9y/Xiv2ITo+WEgvXdw/SdVqhlQ5W2YuFN2NypGOwSUvgwfiK3wgi3RaTmnyfxksb70Ct
+bQsXMGL8DP1f32ieJYI8G1cwFHM8qrQAcmzSlK6hJiEHBhapqwUX47oE/peHVoJ72XqYOw65cfj1rLhU
+sKLCFrQHPaOZZOHF88wRNuka/mP39KNtIhv/fWTuaQrzFMB+UhNfoEHaRcOoElCLlBC5hIQcBeCp1XR7
+6lmzqEbJt1CYrRVlSwWpHWw7hUjnx/80KIJaAT4yy4tPycSyT2pVSIy3QDPYEQ0uJ7fBc7AjHo9PtxoP
+2xHPBIzy1TaI9ndEFLkzvxlPRz831fOyOB/NRBlkqCIkJr8ItsovcuzymyCQvgCFmhk5dbnnjNeuI6ZQ
+ne95XJlqFIInEaJxr4aRwjikVgjbfWrtgdq6jp3cxg4uQyvT9q6i9bSt18B7uFLez68m9Xp+dFtMR20x
+Hd8W03FbTCe3xXTSFtP922K63xbTg9tietAW08PbYnrYFtM3t8X0TVtM394W07dtMX13W0zf9XYPSnXe
+Q+VBBjr7vwy57eLR0me28uhrJKDzbmpbtlNwC2vCi5kfhNwxiU0x4xs3ivFAqIbZgoHjReC4QFn4mbF8
+buAWsfjs5T7/oBEF8bs9u92Kpsp1Q+Bfj5PTps+IZU6OTW7u80rDsnLI5Bgms61llIZzHb1tCraAHWwJ
+e7MF7M2WsP+1E2wRgzeA5luA5luS/WIn2K3Inm4Berol2S93gt2K7NkWoGdbkv23nWC3ItveArS9JdnR
+FrCjLWHvbQF7rxVs3Q7Kex8ChmDmu5hshGZZZhWhcT6lfVsbbHhl3xSzKNzYBbu5ZzI/WNM+asijuF9j
+753/IlO/uOI3YO21G7aP6twE7XgVeuc3wRB0v773dF3oWQlnBCgNAAzvCiBK8d50rekM7MVYpdBfGcIg
+UA0cG5UDoCh9uUSi6PxRHSKcCnh916zZz3RAET/WRtDI8VPB92uzfuOHGHuaMFjTcro+RS5qWiB3TgWP
+dBjFDBJj07Wj6Sua4Wd1y0/Vn0uhqzjOsJIZIY9huiAdTODDz37gczyXWUCIy/aShjHwo1c/a6N02RGs
+YlDQVXirCQxA8DAHwIzuNDDbazy4CHMK6+S3x3vqnZ0KC8K+YIKkH3/ApyPaXq87ttSa4CqXhWVPOE0n
+B4VTy055Rw26FsYlAQ3wKI8IKxrvxkOxl1IpwJpfW94KoDcfhxXU8H3EpyuPrWLXA//Ao4piOQ7mza1N
+FqnPC/BQcl1/TLBmj89U6VfJv+v27CdM7CA5pIlKiiAJETzMjfDccVjEZBYibtligiVlugUiydKNIyYm
+AmYupgmZ6+okc8bYX4hMxZGUW8X0JJE7Aq3xUDXUUCdSjm9BHgGopw/B7jdRWaTvJ04JrpZPCZwz+Njl
+/VnfZA74sQWgBQMV2LHlVWxSSD3HmO/jj8cmntqOMd8nEh8psyiS++ZiwxymqOW4G7HLjp5y6m7USueP
+mTBg1iRC6CVVyDTTr1FLH62QRi1zFGynoJT94wVB2PXZoRhPDwUPX/fkVxWtDoUJMhtA9h/3MiJps5ri
+zhKScU8Jz+czgAecwcwyVYPfl/A8FQloQhe5Ar1gjUwiwQ8oFIH+SCAf9NSwjqU4Ftam+/syL+C60R6T
+GZQTCr6bCMXEYZZ6JHQJLhwYWd5EShweIyW/CtrFz8xIEnhRAoi0Sbddn835xpK6XaPR0KK9RsNcG6My
+bSjwd2NMf1Cr9GrBQ3gKfBmCOwAjB+w4Mdl9kz0w2UOTfWOyb0323Uh/8rxPPlZiEnwYGuew7DCe4p9n
++Oc5/nmBf14aDeCIf0PDwsYT/IMrL9oSocU0LE1Hj/6M+WkYt5mWRw9pTiYsH+LcPHqoHAkI+/OYmHVy
+FABSNRS9ju90OgOPsIcCT7eijMbgX8msHGxgXiYTtKPR6NROAKI/3k7gaCqRGwZ0y3EcQmyHC08coir7
+NVNs9eI5d557R8otU2PlMXDTiWZGGcZRO5yc51Kyk8BVFZSneMqLjANqqjDCMGusmC7bYIYwGuIkwMB1
+j9oQU58xtBPmWPhsyylZYO5HqxAW3pjNK+UnVsy3CC3W88Djsl0635WeLojHkfs7FzZE7Aag6fjqK/ZF
+SphQw4FQwqNao5CMD5hIgA5S6KouGOudlYIwMH0wvHuCfJxJJMACt44GMoQuKVlGulLBRGQJ7UqDwnMi
+Obfarh+noWUXWAuUw1iJ5h4Qjw+WwbqLlAox7rNB/0FPudpMJI5GkwA/0U28jIBxhX34q0BITJN5HvI/
+Sq4VeYOcIA59kdJEHFJZloQCaR6Tr+2tQmWZUTvLItvlfkw335omGjTdfqLRFolmuvHNMvCBgoLEyWoE
+s25+GvYoN1z8fjRQO9doNZ1KR4R4pQq+SFSQ691MTtgJVRSBZdIWSbdKYcudSgt4GUWWdPGHBWVNgGqc
+bm6K5y0nDKvUqWIBE8zSP7a1gPvEM3VYLHYUxF1TiJGtcOLGeGmxsIlbUhjxSGzZQHeTGuE2p0A4DsIx
+nuPWZx8m27UEXHxTsWu6lDur0jQlgDH9M02XLz4hHXioAubuBqs+fEP9ExuXKFAy9vI77T8QvOlaCQW7
+0tDFnq3m7ILys5N7jcDrnjLFvwTMqQGWz0NHWPrLeqXtfbkJlV4RFv1wq8LoaFcqaBYVCeeVwWhtXW7a
+ksahV3OFnZZMl5NBfiv4uTY8C/56PJO6mm1yFJTW8uLS2sYwHt0h778t7vC0kcHmL6u3uJuwBe8yEZlN
+KeiCheAHW3F4+pflcOYkUz4XGNyYyp87kapKQQYFwiG14jT/i3Ma48Q/kNOpWm/F9Nlf1Yi3CqARsD6A
+zvEjhfiYHdzHhVP6w5OzJPDS7jK01IadDVtFVUg7Knqx4xaHXLjTdtd4wiGipeVstiN2lIXymvPILQzQ
+HTMiHX15DK0mit12opy1mSh073tuhfWqrQZce6eyRI5cISX9jvSKCc221wc51/dslDq3MYvl6AB3XZzk
+0B7m8Wk6kXMUbZ3ck8chbUnhQvRpg7lQwk8Th678YO3L/AxKFEoFX7P+W4rMoWKGQrYcxPvawVSeO2sW
+gtEY2nbpE90OpewE1X0SRWYC9erVauRl8WJM/UXrjBFd410Q4EL9JjkxjwNJbUWIBI9MdSoWbHG5hWSv
+29+2cbhc90Z1uROp5LFVy4miF4RIYLiU1x4J6i6ZETLRepEsflGzMHcqu5mkyQi6TO5JanN8FJeM9OIu
+6f9rcLbcD1azeTu5734/AK+vX45q8oKEzdMzQ88Iydwa+I/08jnOCSjZ0m0nIujcbxCTSLTCXVdq+9kI
+i8i9hcCofxNbUpbvILhLFBtKr3+pawasarjcfbyNvSzo8/Fodx43C7UYC+eo0w030g+2FNfstbgyBI12
+H2XN1icqB25/kvjE8v7yWD8u3AHG2mkthlfaVdQSmEsQysgymHGL2ZOlRBUhapX55M7ldnm8+xBgTuyX
+D9228p0nMh1NSq0mAbB1XBVMLiHSaBlYQeOEJvhIgdVto6r/roClMsBckYZbRivTFmooMs91FXgUdu6V
+yE5n0Derm3mbKhgpKbppheFW43iKMVmbwTyz/KQmKdaD5J7DKP9d6KtQ1luPbbrWjUzEKY1jK4czW48u
+Tc2/07Eh1Fs5bZxDgpbvrejc87o0EaYtHDc0HE7vwm+zaGXPhfRF+DX9/P1yciSJrPzTvfHdemK9F25r
+XxscWmnbJtKXrqrzpNiTTtgHDcWxMhRiLjThyA0sxVFG0IrCIXYf5WhMilpRib9XWMGPPo1FIb+FtRw3
+l/TLemxV2S/FuXV9vxxCDZMT4nckSlnXT0NVim6LyoMtScnVcy1WGNy1sOCym0k2X+ExL/t8aUcringY
+v/htZXmqQqkWFSitjgaPOBorup0TbHRUU5iZEFvQeCzcqSJWwYek9imFnfEq9COZzUG2CoSEhanpOIWK
+Kq9dTDPCaS583yKaJXWjoWHgezcJNI5g6Cof0t5n733PveJyuEn1ZwhjZe1tEnwU4O82/ApSAF/rpMD6
+sz7WqU63E7nHF9yP+1hyGtp/TXLDctC02er0EzQTblvotMX3BBxixqhrHYROPyeFn3HkeLPKMcXIiBV1
+kxPbCZWbBIHHLV9TrTgliVBo6zdLYAr9I4Sd6ra871QRE+VNCoJjlFqIyVN1A21bklmcIZV3phUD8TWz
+iHK6k+Q7+EjDPPDTQqibunLCSXXutqQCqIJ1bCbb0pifyXaICxawGfNEzzALGDYplCJPpvXC9f+fXwp+
+Pa7hFwRmWEOfasNS/cqoXMBSFP5Nq8QSc8mkTsiuR1RrM9VW353yKL7w3a7ru9VqsZPAuRmLWpn4sYd3
+Oow9TGvbiwy2x4ZXJrUZXo1GVAf5KimCLIKblxhpy76VfUngGZIo4Uc+2BowsRme4V40IiT0CBDs5+kR
+DcswF5brj/FJdtchXXLgECHwwSaGWHTk4cHDPj6SqarDCrlgvMeSZLrbUiQfGIEQkgbAkF7DoWc9u/KA
+8tWjS/VJs7HuyxPzgm7kyO2V6pjyyLaWXGTc4VsIMKV9XJW+SB0upOdRw0oZ7dDyo649V2zYgN/FUPeD
+UbM4Mj58+KDIqM53/aDp+kHfdVLfdaLvOa3vOdX39Ot7+vqeYX3PUN8zru8Zt69Ds5TCzhc9VyejQ9PH
+7OQYUyq68PnJGTs6fojZofgAvjz4TrOoB6JWe4P7G5ra9nLUfrvLnmfUgErtRahWe8UXFAwzZRR12Wur
+sqsmw4838VxMh7JZVU0ZFYSnVjT/w6fT13Xy/voD/a+FzAu8/Hov+vqOOfk88DzZ4A9lxZd1rPjyyy25
+oHWegpDk9QxlDuSX04lPJSVJ4nO59sqevNiIZ7i/gdSWe/9qLTxgDLcWXW2MLx62qR1ehUzLIRZbVzwS
+q5ZInckFCOqjFVitvLjm4Q1zAnu1oJsGoAUoelAX21s5WNRMvABErMjwFjimbxgHBwcG1eEuw6M36EBg
+vVjGN6gpSCqsiC5X8uVCHJ4vrPCKh/36F5UMET4a4/0K77ucciHwISo2vtFHLEdG9Hu/30f32ikKRFqG
+oE4QQcOejtzbJgcvo6jTJIgq2xeJ66pnFmhPSMDYYtTZLbQIeqXzDePjXvQppUKwz8REEUFqDlElRA70
+O2W5+K0Ieaga1LGwMsExkh2MWuCtPX8x9GwNWsBu3DEybLHhngwj3R0yGmHXLikK5jCork0C7E4VqKpD
+fgc/G+oeokBTtctL/L2mj/qYyHgd+NwwVRPjZzSFsASaVm39NdoCxbpBoQUIgLRbo8GEpD4oJmzFaBdM
+yj9XQYxWjj17+7M8/BGFFmWVmojeM7dYgMG32G/YmN4jQOaJTcACyZRfAOUEq0ly6U00RUvH8B0rFn36
+6eUzdv/o20G/4hOfRde39If0cjfLEx4xcYGmqAeYRdq5b37hW2g8qnnJUW4nUOJQe7per+buZP5WX8VJ
+6J15y6WCjPo+bHOpOO/f7zZqBE34JXRR+GGwxpP0XCKh0NeIFAIVLn3J4cy95pi46K0WPjzGXcMEGKKN
+lqBpTjTnPI76+FI8uZ9BWihfwDeHFuD42A+46iRXSNA/hB+wRkOU7iDm9JC9wnf+gcZK74P+FSd4VHgr
+nxVJjyta9QuTHDVXjFMSX1Vhm3setsF1sIPX20VLReT2RXFVTn2SxqCs8H0ovja8M6Zm3Zja2xygonPG
+ugO1jaQ707bRuIfK3lDKQLkPjPzmYAbFizARKH4BrQEsbM9JPVcFc8IkyeAWZazKDlBKMYVYti6oZ10p
+PWinEF2eMBF11gY8GkagJuW2lNPpgsHnHlUGIiJU3OhtN3oZdeAMr9VPmvBCEKlIoipzxMSTVjoHV8Xk
+LUHTDhK6SIEDN7qGQhYkh4jeIYSw3MQsgbWa8W7x7XbYUtQlUHpHpNWjuDdMIl8PwRFyMmu5905OrIg/
+vD+O6dWoIOXzp8+ev3j5t+8v/v6PH169fvPjP396++79z7/869f/tSa2w6ezuXt55S38YPkbGKzV9Xpz
+8/vg6Pjk/oOH33z73f6hYVaBu/41gP7IhnlkMNQRFppUj/bhSQ8L0hEs0es5x92Kpzcxr7wSj0rTUYGo
+5OgHt/CCNXeYlS4kwAjj6iWeYzOyIMkhm3y1KFHVVxcTy/YY01cJUq0I4+ws2WA8LpilcrOk1VHlBq28
+z+vDusTFuolDWyhTjV8aDk6rBSoklaA7VaObyQAmQuUNdUVIe+w+TvVBYhPlM0lcT12JIJcqaUlZAb9R
+WOltg7wvbgwesoIfMCdqq5Fs8+6GLaoQ4KIUvNBDNnHla21NkOyrt0/p8/6RJuvCP0LpZeweiiInI/b4
+MYD4N+uWH2HeAjx+8oTd79W8+wHIuc9+SLGb7H5KyrGOlOOm/ByB/liUYDmTydRDfeING9YN4St29KCH
+47xfM85jMc5jzTiPs3Eem2Uh7J/oRnvSbrQndzDaYxrtCQ32IQxW0eRkNKoZZa6UzT4WycIaG6A1+ygv
++HOifn1coecgf6iTN4zqt3pO0FxKT6Y2ozUOJMkgSDfIJr3sYhRujhHkXmHh5dpXUSCPtvFDV/laQo+d
+1WdMl97HiokU6kXIcNRkRpbudYBX4DAVfKCsk4W1hNM7cumLbkW1xvy7F+ndfqTpyjIYlDt1xlTv0Nz0
+2Aa3yYkWkxAqQYgiihoYTzQgyEkgu5EQOv+htiO5H0aPCEEirJXv/qaWzVTugyqjwexFtvU1dYaTkfrF
+kMPyq3CPRjXpJKTuLd7LiAOdjJSbujLbOZsoEY9VbwAkRqQ8yqfCQIdX+D7wsLupZEWBrr978/xN17Hp
+HZC9U/bU9bHGiD0PluS733S9YMYgKsTXhXt848Y3Bby5lTggusC3jHeHm5HMxkEvm5Hx3k+3p0vU06Dw
+pDRHtIBVbp17H6iJh8PiLptl221eBEortfLNmEmNtwWYzaIjfRDVvybDy1G9385RLBPXxX8AC0ofgYwa
+06lTdI+3xiZ5tEu+dpHZKdlqC581HiQWPtcuk+5zdzq9a+G2FqPqNlutGqhZ2Sy0P0xf/nA1aa+Q/0f6
+suDhjP+Ir9TuxhZ8BOexxG91xxriYZujDQFuLG99nGkvUYi2VbASiLyplq8zXsGTnKS0wCNpUqCrbC6X
+OshMECUduC81zs5zrjRb1pKJWJUJPw2v0o0tZQQyCeJ5BlkadWHxC4M36zD1SgRX7/wBCad1t9FLK0UC
+abKrhjT9AucAvPadiSUUhb7NqCg0y/RYlOFKOKudaI0J6CXQ5UG1RFPQhMRC56Rq5pWnmCb0qT6AFYIQ
+ki2d6ZVigPzTF5tuIMu+9RSd6RpGc388dVJ1/951HO7rIbzCdQjAMObU2CjCoXzT2jGIp7VjEI81Y8j3
+z48hl01MFefdyMWdLnmbE7fwaa3CHVjRyHzj0ML8Yqxk7uOPJLwEFuan4Zb8FU+Da8ogFonIeI94zm9y
+ecAQAFpYZoW27rllz0XysthmEkS8JfTa82bt9mtu6zUPUOYmRtkFPcWZf9CQRE6BcZnJ0CvH3Xc5rorz
+NslbHDK+y8BZLT0XX6USse4Er6bRzi5JIwhxozWYJrDKmPrsPL1d6Eb0lrrYwiKQxOeQf01byIsA1nGB
+L/fuHDeKXV+tbjuytQjyThhbTj+xbbwGqTnqrz3ES2FEnMOSA/+iz7kW8UO2Z0dBiqj6LKKV61Hd/W+F
+wOuaDkeKiQZ95aHrHDPlPQ/nmXh7j6w6RPcMu/CwoDlRLz17Vab44yltRPdMLXFSxBbyvAukH+XT5/8B
+8MWlswQl/lKrCpPL9sqQIdDlzutUY3KpyUYu0Nsm26dEUz49uUpU3X2SAtamnZWE6WcFr5csSQugzHSl
+Ws1PATb0qkV7c0ou8ej2GwBI62oyydloQQmz0gy5tJUEcZr/sMStfBhQV1yaQdJLTihrAy4obVVyZVkj
+dGRbw6q6Zgyp0F+qXSI8lf5U6VKTq5mt+udxc7whFNUsRGMrSW8QefeVPL9J/vmkuPdH0eIydBdu7F7z
+FwJPDIhi1UqTxqTNrq8DJ+dUTfQpt/Ws0kalOkFWSbJnmcVlsibOLY6i5UXw3HKxp9/8piU/jKXxcmfh
+Gpd2hfwFrsPdUTNI9dBa3w8trbTrd8cV6+JebcKAUh+k4dcqRGGdVjBlVv3r0AqdpDpIX6dUJ9n+CwWW
+yZ+tRJK2u9GktKCBHDKWYwD1mkr1mn5u6qVMSijrGpnM5Pw95FHgXeP6c45b1orteLBSiZOFEDrGVsah
+oTyyOUzPbJKDjPI5ivpYgxJhh2EaxS3DlY9mu0KLGz0L/Jj7cXeiLoMd19l1qUMTfYpPVZiJpsTa3NPS
+nvqEAl4NHG0WSzOgjkrZKz6uzgUiFbrBDKXWoAw2IhFkg2sliyZhKoEv0yY9Vs1wrY6ztC003IxOWQLD
+gm+98q1ygVVh54p0ZMQSlAzMpyqvLLPzqfMfJTHpH6eYAAA=
 `,
 	},
 
@@ -0,0 +1 @@
+{ name: "both.libsonnet" }
@@ -0,0 +1 @@
+{ name: "exact.libsonnet" }
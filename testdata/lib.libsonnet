@@ -0,0 +1 @@
+{ name: "wrong lib, imports should be relative to the importing file" }
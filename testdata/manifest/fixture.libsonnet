@@ -0,0 +1,11 @@
+// Shared input for the manifestation goldens.
+{
+  name: "zażółć ☃ 😀",
+  quote: "say \"hi\"\\n",
+  numbers: [0, -0, 1, -1.5, 0.1, 1e-7, 1e21, 9007199254740993, std.pow(2, 0.5)],
+  flags: { yes: true, no: false, nothing: null },
+  nested: { list: [[], {}, [1, [2, [3]]]], obj: { a: { b: { c: "deep" } } } },
+  multiline: "line 1\nline 2\n",
+  hidden:: "not shown",
+  "key with spaces": "v",
+}
@@ -0,0 +1,4 @@
+{
+    parent: (import "../lib.libsonnet").name,
+    data: importstr "data.txt",
+}
@@ -65,7 +65,7 @@ func (t *thunk) getValue(i *interpreter, trace *TraceElement) (value, error) {
 	context := TraceContext{
 		Name: "thunk <" + string(t.name) + ">",
 	}
-	return i.EvalInCleanEnv(trace, &context, &t.env, t.body)
+	return i.EvalInCleanEnv(trace, &context, &t.env, t.body, false)
 }
 
 // callThunk represents a concrete, but not yet evaluated call to a function
@@ -84,7 +84,7 @@ func call(ec evalCallable, arguments ...potentialValue) potentialValue {
 
 func (th *callThunk) getValue(i *interpreter, trace *TraceElement) (value, error) {
 	evaluator := makeEvaluator(i, trace)
-	err := checkArguments(evaluator, th.args, th.function.Parameters())
+	err := checkArguments(evaluator, th.args, th.function)
 	if err != nil {
 		return nil, err
 	}
@@ -107,6 +107,9 @@ func makeCachedThunk(pv potentialValue) *cachedThunk {
 }
 
 func (t *cachedThunk) getValue(i *interpreter, trace *TraceElement) (value, error) {
+	if rv, ok := t.pv.(*readyValue); ok {
+		return rv.content, nil
+	}
 	v, err := t.pv.getValue(i, trace)
 	if err != nil {
 		// TODO(sbarzowski) perhaps cache errors as well
@@ -162,6 +165,16 @@ func (f *bindingsUnboundField) bindToObject(sb selfBinding, origBindings binding
 	return f.inner.bindToObject(sb, upValues, fieldName)
 }
 
+// potentialValueUnboundField is a field which doesn't depend on the object it
+// ends up in, e.g. one computed by a builtin from another object.
+type potentialValueUnboundField struct {
+	pv potentialValue
+}
+
+func (f *potentialValueUnboundField) bindToObject(sb selfBinding, origBindings bindingFrame, fieldName string) potentialValue {
+	return f.pv
+}
+
 type PlusSuperUnboundField struct {
 	inner unboundField
 }
@@ -199,7 +212,7 @@ func (closure *closure) EvalCall(arguments callArguments, e *evaluator) (value,
 	context := TraceContext{
 		Name: "function <anonymous>",
 	}
-	return e.evalInCleanEnv(&context, &calledEnvironment, closure.function.Body)
+	return e.evalInCleanEnv(&context, &calledEnvironment, closure.function.Body, arguments.tailstrict)
 }
 
 func (closure *closure) Parameters() ast.Identifiers {
@@ -18,6 +18,8 @@ package jsonnet
 import (
 	"errors"
 	"fmt"
+	"math"
+	"sort"
 
 	"github.com/google/go-jsonnet/ast"
 )
@@ -153,6 +155,11 @@ func (*valueNumber) typename() string {
 	return "number"
 }
 
+// makeValueNumber doesn't check v, but Jsonnet numbers are always finite.
+// Results of arithmetic, which may overflow or be NaN, are created with
+// makeDoubleCheck instead, numbers from Go data with valueFromGo, and the
+// parser rejects literals which don't fit in a float64. The other callers
+// create integers, e.g. lengths and indices, which are safe.
 func makeValueNumber(v float64) *valueNumber {
 	return &valueNumber{value: v}
 }
@@ -244,10 +251,25 @@ func (f *valueFunction) parameters() ast.Identifiers {
 	return f.ec.Parameters()
 }
 
-func checkArguments(e *evaluator, args callArguments, params ast.Identifiers) error {
+// optionalParamsCallable is implemented by callables whose trailing
+// parameters may be omitted by the caller.
+type optionalParamsCallable interface {
+	evalCallable
+	// RequiredParameters returns how many leading parameters must be passed.
+	RequiredParameters() int
+}
+
+func checkArguments(e *evaluator, args callArguments, ec evalCallable) error {
 	// TODO(sbarzowski) this will get much more complicated with named params
 	numPassed := len(args.positional)
-	numExpected := len(params)
+	numExpected := len(ec.Parameters())
+	if oc, ok := ec.(optionalParamsCallable); ok {
+		numRequired := oc.RequiredParameters()
+		if numPassed < numRequired || numPassed > numExpected {
+			return e.Error(fmt.Sprintf("function expected %v to %v argument(s), but got %v", numRequired, numExpected, numPassed))
+		}
+		return nil
+	}
 	if numPassed != numExpected {
 		return e.Error(fmt.Sprintf("function expected %v argument(s), but got %v", numExpected, numPassed))
 	}
@@ -261,6 +283,8 @@ func (f *valueFunction) typename() string {
 type callArguments struct {
 	positional []potentialValue
 	// TODO named arguments
+	// Set for tailstrict calls, whose arguments are already evaluated.
+	tailstrict bool
 }
 
 func args(xs ...potentialValue) callArguments {
@@ -273,9 +297,8 @@ func args(xs ...potentialValue) callArguments {
 // Object is a value that allows indexing (taking a value of a field)
 // and combining through mixin inheritence (operator +).
 //
-// Accessing a field multiple times results in multiple evaluations.
-// TODO(sbarzowski) This can be very easily avoided and currently innocent looking
-// 					code may be in fact exponential.
+// Fields are bound to the object once and the bound fields are cached, so
+// accessing a field multiple times evaluates it only once.
 type valueObject interface {
 	value
 	inheritanceSize() int
@@ -283,6 +306,8 @@ type valueObject interface {
 	assertionsChecked() bool
 	setAssertionsCheckResult(err error)
 	getAssertionsCheckResult() error
+	getCachedField(key objectCacheKey) potentialValue
+	setCachedField(key objectCacheKey, pv potentialValue)
 }
 
 type selfBinding struct {
@@ -324,6 +349,8 @@ type Hidden int
 const (
 	withHidden Hidden = iota
 	withoutHidden
+	// onlyHidden is only meaningful when listing fields.
+	onlyHidden
 )
 
 func withHiddenFromBool(with bool) Hidden {
@@ -338,9 +365,18 @@ func withHiddenFromBool(with bool) Hidden {
 // so we have a special value for no error and nil means that we don't know yet.
 var errNoErrorInObjectInvariants = errors.New("No error - assertions passed")
 
+// objectCacheKey identifies a field bound to an object by its name and the
+// super depth it was found at, as the same name may refer to different fields
+// at different depths.
+type objectCacheKey struct {
+	field string
+	depth int
+}
+
 type valueObjectBase struct {
 	valueBase
 	assertionError error
+	cache          map[objectCacheKey]potentialValue
 }
 
 func (*valueObjectBase) typename() string {
@@ -371,6 +407,17 @@ func (obj *valueObjectBase) getAssertionsCheckResult() error {
 	return obj.assertionError
 }
 
+func (obj *valueObjectBase) getCachedField(key objectCacheKey) potentialValue {
+	return obj.cache[key]
+}
+
+func (obj *valueObjectBase) setCachedField(key objectCacheKey, pv potentialValue) {
+	if obj.cache == nil {
+		obj.cache = make(map[objectCacheKey]potentialValue)
+	}
+	obj.cache[key] = pv
+}
+
 // valueSimpleObject represents a flat object (no inheritance).
 // Note that it can be used as part of extended objects
 // in inheritance using operator +.
@@ -383,7 +430,18 @@ type valueSimpleObject struct {
 	valueObjectBase
 	upValues bindingFrame
 	fields   valueSimpleObjectFieldMap
-	asserts  []unboundField
+	asserts  []objectAssert
+}
+
+// objectAssert is an assertion of an object, split into the condition and
+// the message, so that a failed assertion can be told apart from an error
+// raised while checking it.
+type objectAssert struct {
+	cond unboundField
+	// nil if the assertion has no failure branch of its own
+	msg unboundField
+	// Location of the failure branch, where a failed assertion is raised
+	loc *ast.LocationRange
 }
 
 func checkAssertionsHelper(e *evaluator, obj valueObject, curr valueObject, superDepth int) error {
@@ -399,11 +457,37 @@ func checkAssertionsHelper(e *evaluator, obj valueObject, curr valueObject, supe
 		}
 		return nil
 	case *valueSimpleObject:
+		sb := selfBinding{self: obj, superDepth: superDepth}
 		for _, assert := range curr.asserts {
-			_, err := e.evaluate(assert.bindToObject(selfBinding{self: obj, superDepth: superDepth}, curr.upValues, ""))
+			condValue, err := e.evaluate(assert.cond.bindToObject(sb, curr.upValues, ""))
+			if err != nil {
+				return err
+			}
+			if assert.msg == nil {
+				continue
+			}
+			cond, err := e.getBoolean(condValue)
+			if err != nil {
+				return err
+			}
+			if cond.value {
+				continue
+			}
+			msgValue, err := e.evaluate(assert.msg.bindToObject(sb, curr.upValues, ""))
+			if err != nil {
+				return err
+			}
+			msg, err := e.getString(msgValue)
 			if err != nil {
 				return err
 			}
+			// The stack trace ends in the object field thunk, as if the
+			// failure branch raised the error itself.
+			failure := &TraceElement{loc: assert.loc, context: &TraceContext{Name: "thunk <object_field>"}}
+			stackTrace := append(e.i.getCurrentStackTrace(e.trace), traceElementToTraceFrame(failure))
+			rte := makeRuntimeError(msg.getString(), stackTrace)
+			rte.assertionFailed = true
+			return rte
 		}
 		return nil
 	default:
@@ -430,7 +514,7 @@ func (*valueSimpleObject) inheritanceSize() int {
 	return 1
 }
 
-func makeValueSimpleObject(b bindingFrame, fields valueSimpleObjectFieldMap, asserts []unboundField) *valueSimpleObject {
+func makeValueSimpleObject(b bindingFrame, fields valueSimpleObjectFieldMap, asserts []objectAssert) *valueSimpleObject {
 	return &valueSimpleObject{
 		upValues: b,
 		fields:   fields,
@@ -445,6 +529,12 @@ type valueSimpleObjectFieldMap map[string]valueSimpleObjectField
 type valueSimpleObjectField struct {
 	hide  ast.ObjectFieldHide
 	field unboundField
+	// Position of the field in the object, used to output fields in the order
+	// they were defined.
+	order int
+	// Whether the field name was computed rather than written as an
+	// identifier, see std.fieldIsComputed.
+	computed bool
 }
 
 // unboundField is a field that doesn't know yet in which object it is.
@@ -514,7 +604,6 @@ func findField(curr value, minSuperDepth int, f string) (*valueSimpleObjectField
 				return &field, curr.upValues, 0
 			}
 		}
-		// TODO(sbarzowski) add handling of "Attempt to use super when there is no super class."
 		return nil, nil, 0
 	default:
 		panic(fmt.Sprintf("Unknown object type %#v", curr))
@@ -533,14 +622,69 @@ func objectIndex(e *evaluator, sb selfBinding, fieldName string) (value, error)
 	return e.evaluate(objp)
 }
 
+// objectHasField reports whether the field exists, without binding it to the
+// object, so its value is certainly not evaluated.
+func objectHasField(sb selfBinding, fieldName string, h Hidden) bool {
+	field, _, _ := findField(sb.self, sb.superDepth, fieldName)
+	return field != nil && (h != withoutHidden || field.hide != ast.ObjectFieldHidden)
+}
+
+// objectFieldOrigin reports whether the field exists (hidden or not) and
+// whether it is defined by the object itself, i.e. the right-hand side of the
+// outermost +, rather than inherited from the left-hand side.
+func objectFieldOrigin(obj valueObject, fieldName string) (found bool, own bool) {
+	field, _, foundAt := findField(obj, 0, fieldName)
+	if field == nil {
+		return false, false
+	}
+	ownSize := obj.inheritanceSize()
+	if ext, ok := obj.(*valueExtendedObject); ok {
+		ownSize = ext.right.inheritanceSize()
+	}
+	return true, foundAt < ownSize
+}
+
+// objectFieldLocation returns the location of the code which defines the
+// value of the field, or nil if it was not defined by user code, e.g. by a
+// builtin or in std.jsonnet. For a +: field it is the location of the
+// right-hand side.
+func objectFieldLocation(obj valueObject, fieldName string) *ast.LocationRange {
+	field, _, _ := findField(obj, 0, fieldName)
+	if field == nil {
+		return nil
+	}
+	f := field.field
+	for {
+		switch inner := f.(type) {
+		case *codeUnboundField:
+			if loc := inner.body.Loc(); loc.IsSet() && !stdLocations[loc] {
+				return loc
+			}
+			return nil
+		case *PlusSuperUnboundField:
+			f = inner.inner
+		case *bindingsUnboundField:
+			f = inner.inner
+		default:
+			return nil
+		}
+	}
+}
+
 func tryObjectIndex(sb selfBinding, fieldName string, h Hidden) potentialValue {
 	field, upValues, foundAt := findField(sb.self, sb.superDepth, fieldName)
 	if field == nil || (h == withoutHidden && field.hide == ast.ObjectFieldHidden) {
 		return nil
 	}
+	key := objectCacheKey{field: fieldName, depth: foundAt}
+	if pv := sb.self.getCachedField(key); pv != nil {
+		return pv
+	}
 	fieldSelfBinding := selfBinding{self: sb.self, superDepth: foundAt}
 
-	return field.field.bindToObject(fieldSelfBinding, upValues, fieldName)
+	pv := makeCachedThunk(field.field.bindToObject(fieldSelfBinding, upValues, fieldName))
+	sb.self.setCachedField(key, pv)
+	return pv
 }
 
 type fieldHideMap map[string]ast.ObjectFieldHide
@@ -573,13 +717,217 @@ func objectFieldsVisibility(obj valueObject) fieldHideMap {
 func objectFields(obj valueObject, h Hidden) []string {
 	var r []string
 	for fieldName, hide := range objectFieldsVisibility(obj) {
-		if h == withHidden || hide != ast.ObjectFieldHidden {
+		isHidden := hide == ast.ObjectFieldHidden
+		if h == withHidden || (h == withoutHidden && !isHidden) || (h == onlyHidden && isHidden) {
 			r = append(r, fieldName)
 		}
 	}
 	return r
 }
 
+// objectFieldsSorted is like objectFields, but the result is sorted by
+// codepoints. This is the order of fields in all introspection builtins and
+// in the output, so it doesn't depend on how the object was built.
+func objectFieldsSorted(obj valueObject, h Hidden) []string {
+	fieldNames := objectFields(obj, h)
+	// Byte-wise order of UTF-8 strings is the same as order of codepoints.
+	sort.Strings(fieldNames)
+	return fieldNames
+}
+
+// objectFieldsOrdered returns the fields in the order they were defined if
+// preserveOrder is set and sorted otherwise.
+func objectFieldsOrdered(obj valueObject, h Hidden, preserveOrder bool) []string {
+	if preserveOrder {
+		return objectFieldsInDefinitionOrder(obj, h)
+	}
+	return objectFieldsSorted(obj, h)
+}
+
+// objectFieldsInDefinitionOrder is like objectFields, but returns the fields
+// in the order they were defined. In a + b, the fields of a come first and
+// fields overridden in b keep their position from a.
+func objectFieldsInDefinitionOrder(obj valueObject, h Hidden) []string {
+	included := make(map[string]bool)
+	for _, fieldName := range objectFields(obj, h) {
+		included[fieldName] = true
+	}
+	r := make([]string, 0, len(included))
+	var collect func(obj valueObject)
+	collect = func(obj valueObject) {
+		switch obj := obj.(type) {
+		case *valueExtendedObject:
+			collect(obj.left)
+			collect(obj.right)
+		case *valueSimpleObject:
+			fieldNames := make([]string, 0, len(obj.fields))
+			for fieldName := range obj.fields {
+				fieldNames = append(fieldNames, fieldName)
+			}
+			sort.Slice(fieldNames, func(i, j int) bool {
+				return obj.fields[fieldNames[i]].order < obj.fields[fieldNames[j]].order
+			})
+			for _, fieldName := range fieldNames {
+				if included[fieldName] {
+					r = append(r, fieldName)
+					// Only the first occurrence counts.
+					delete(included, fieldName)
+				}
+			}
+		}
+	}
+	collect(obj)
+	return r
+}
+
 func duplicateFieldNameErrMsg(fieldName string) string {
 	return fmt.Sprintf("Duplicate field name: %s", unparseString(fieldName))
 }
+
+// valueFromGo converts Go data into a Jsonnet value. It accepts the types
+// produced by encoding/json when unmarshalling into an interface{}, and a few
+// more:
+//
+//	nil                                 -> null
+//	bool                                -> boolean
+//	float64, float32, int, int64, int32 -> number
+//	string                              -> string
+//	[]byte                              -> array of numbers
+//	[]interface{}                       -> array
+//	map[string]interface{}              -> object (with visible fields)
+//
+// Each call returns fresh values, so the result must not be shared between
+// interpreters.
+func valueFromGo(v interface{}) (value, error) {
+	switch v := v.(type) {
+	case nil:
+		return makeValueNull(), nil
+	case bool:
+		return makeValueBoolean(v), nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("cannot convert %v to a Jsonnet number", v)
+		}
+		return makeValueNumber(v), nil
+	case float32:
+		return valueFromGo(float64(v))
+	case int:
+		return makeValueNumber(float64(v)), nil
+	case int64:
+		return makeValueNumber(float64(v)), nil
+	case int32:
+		return makeValueNumber(float64(v)), nil
+	case string:
+		return makeValueString(v), nil
+	case []byte:
+		elems := make([]potentialValue, len(v))
+		for i, b := range v {
+			elems[i] = &readyValue{intToValue(int(b))}
+		}
+		return makeValueArray(elems), nil
+	case []interface{}:
+		elems := make([]potentialValue, 0, len(v))
+		for _, elem := range v {
+			elemValue, err := valueFromGo(elem)
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, &readyValue{elemValue})
+		}
+		return makeValueArray(elems), nil
+	case map[string]interface{}:
+		// Go maps are unordered, so the fields are "defined" in sorted order.
+		fieldNames := make([]string, 0, len(v))
+		for fieldName := range v {
+			fieldNames = append(fieldNames, fieldName)
+		}
+		sort.Strings(fieldNames)
+		fields := make(valueSimpleObjectFieldMap, len(v))
+		for order, fieldName := range fieldNames {
+			fieldValue, err := valueFromGo(v[fieldName])
+			if err != nil {
+				return nil, err
+			}
+			fields[fieldName] = valueSimpleObjectField{ast.ObjectFieldInherit, &readyValue{fieldValue}, order, false}
+		}
+		return makeValueSimpleObject(nil, fields, nil), nil
+	default:
+		return nil, fmt.Errorf("cannot convert value of type %T to a Jsonnet value", v)
+	}
+}
+
+// valueToGo is the inverse of valueFromGo. It evaluates v deeply and returns
+// the Go data in the form produced by encoding/json. Hidden fields are left
+// out, like in manifested output. Like manifestation, it fails on values
+// nested deeper than MaxManifestDepth, e.g. recursive ones.
+func valueToGo(e *evaluator, v value) (interface{}, error) {
+	return valueToGoAt(e, v, nil)
+}
+
+func valueToGoAt(e *evaluator, v value, path *manifestPath) (interface{}, error) {
+	if err := e.i.checkManifestDepth(e.trace, v, path); err != nil {
+		return nil, err
+	}
+	switch v := v.(type) {
+	case *valueNull:
+		return nil, nil
+	case *valueBoolean:
+		return v.value, nil
+	case *valueNumber:
+		return v.value, nil
+	case *valueString:
+		return v.getString(), nil
+	case *valueArray:
+		r := make([]interface{}, len(v.elements))
+		for i, elem := range v.elements {
+			elemValue, err := e.evaluate(elem)
+			if err != nil {
+				return nil, err
+			}
+			r[i], err = valueToGoAt(e, elemValue, path.withIndex(i))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return r, nil
+	case valueObject:
+		if err := checkAssertions(e, v); err != nil {
+			return nil, err
+		}
+		fieldNames := objectFields(v, withoutHidden)
+		r := make(map[string]interface{}, len(fieldNames))
+		for _, fieldName := range fieldNames {
+			fieldValue, err := v.index(e, fieldName)
+			if err != nil {
+				return nil, err
+			}
+			r[fieldName], err = valueToGoAt(e, fieldValue, path.withField(fieldName))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return r, nil
+	default:
+		return nil, e.Error(fmt.Sprintf("Cannot convert %s to Go data", v.typename()))
+	}
+}
+
+// valueToBytes converts an array of integers from 0 to 255 to []byte.
+func valueToBytes(e *evaluator, v value) ([]byte, error) {
+	arr, err := e.getArray(v)
+	if err != nil {
+		return nil, err
+	}
+	r := make([]byte, len(arr.elements))
+	for i, elem := range arr.elements {
+		b, err := e.evaluateNumber(elem)
+		if err != nil {
+			return nil, err
+		}
+		if b.value != math.Floor(b.value) || b.value < 0 || b.value > 255 {
+			return nil, e.Error(fmt.Sprintf("Expected a byte (an integer from 0 to 255) at index %d, got %v", i, unparseNumber(b.value)))
+		}
+		r[i] = byte(b.value)
+	}
+	return r, nil
+}
@@ -19,6 +19,9 @@ package jsonnet
 import (
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"reflect"
 	"runtime/debug"
 
 	"github.com/google/go-jsonnet/ast"
@@ -35,9 +38,131 @@ import (
 type VM struct {
 	MaxStack int
 	MaxTrace int // The number of lines of stack trace to display (0 for all of them).
-	ext      vmExtMap
-	importer Importer
-	ef       ErrorFormatter
+	// The maximum nesting of arrays and objects in manifested output.
+	// It protects from unbounded recursion when manifesting pathological values.
+	// Unlike MaxOutputSize it can't be disabled: it must be at least 1, as
+	// only scalars could be manifested otherwise.
+	MaxManifestDepth int
+	// The maximum size of the output in bytes, 0 for no limit. It protects
+	// servers evaluating untrusted code from programs with huge outputs.
+	MaxOutputSize int
+	// The maximum number of elements of an array made by std.range or
+	// std.reverseRange, 0 for no limit. The elements are allocated up front,
+	// so it protects servers evaluating untrusted code from a single call
+	// exhausting the memory.
+	MaxRangeLength int
+	// Escape all non-ASCII characters in the strings of the output as
+	// \uXXXX, for consumers which can't handle UTF-8. Like EscapeRune and
+	// EscapeHTML, it only affects the final output, not the strings made by
+	// std.manifestJson, std.toString and the like, so that all VMs evaluate
+	// a program the same way.
+	ASCIIOutput bool
+	// EscapeRune, if set, overrides how characters of strings are escaped in
+	// the output, e.g. to write / as \/ or to escape U+2028 for embedding in
+	// JavaScript. For every character it returns the text to write, or false
+	// to escape the character as usual. The result must be valid in a JSON
+	// string.
+	EscapeRune func(r rune) (escaped string, ok bool)
+	// Format numbers in the output and in std.toString exactly like the C++
+	// implementation, e.g. 0.10000000000000001 instead of 0.1. Useful to
+	// compare the output with the C++ version.
+	UpstreamNumberFormat bool
+	// End the lines of the output with \r\n, for consumers on Windows.
+	CRLFLineEndings bool
+	// Start the output with a UTF-8 byte order mark.
+	UTF8BOM bool
+	// Escape <, > and & in the strings of the output as \u003c, \u003e and
+	// \u0026, like encoding/json does, so that the output can be embedded in
+	// HTML, e.g. in a <script> tag. It takes precedence over EscapeRune.
+	// Strings made by std.manifestJson and the like are escaped when they are
+	// written to the output, so they are safe too.
+	EscapeHTML bool
+	// Allow negative indices in slices (e.g. arr[-2:]), counting from the
+	// end. Upstream Jsonnet doesn't support them, so it's off by default.
+	NegativeSliceIndices bool
+	// Silence std.trace, e.g. in production. It then just returns its
+	// second argument, without evaluating the message.
+	DisableTrace bool
+	// Write std.trace messages as JSON lines with the fields file, line and
+	// message, for machine parsing.
+	TraceJSON bool
+	// TraceOut receives the std.trace messages. By default they are written
+	// to os.Stderr.
+	TraceOut io.Writer
+	// Indent std.trace messages by two spaces per level of the call stack,
+	// i.e. per function call or lazy value whose evaluation is in progress,
+	// so that traces from nested calls stand out. Messages written as JSON
+	// lines are not indented.
+	TraceIndent bool
+	// Evaluate strictly, for debugging with std.trace. Normally a value is
+	// evaluated only when it's needed, so traces fire in the order in which
+	// the output needs them. In strict mode locals, function arguments and
+	// array elements are evaluated in source order as soon as they are
+	// defined, so traces in them fire in that order too. Object fields are
+	// still evaluated on access, as they can refer to self. Programs which
+	// rely on laziness, e.g. with locals that fail when unused, may fail.
+	StrictEvaluation bool
+	// Jsonnet numbers are float64, so std.parseJson rounds integers beyond
+	// 2^53 to the nearest representable number, like most JSON parsers.
+	// With ExactJSONIntegers it fails on them instead.
+	ExactJSONIntegers bool
+	// Warn about local variables which are never used. Warnings don't stop
+	// the evaluation, they are passed to WarningHandler. Only the evaluated
+	// snippet is checked, not the files it imports.
+	WarnUnusedLocals bool
+	// WarningHandler receives the warnings about the evaluated code.
+	// If it is nil, warnings are discarded.
+	WarningHandler func(Warning)
+	// Reject code which calls or indexes a literal that doesn't support it,
+	// e.g. 1(2) or null.x, before evaluating it. Such code is rejected even
+	// where it would never be evaluated, e.g. in a branch which isn't taken.
+	// Like WarnUnusedLocals, it only applies to the evaluated snippet, not the
+	// files it imports.
+	StaticTypeChecks bool
+	// Make the bitwise operators (&, |, ^, ~, << and >>) fail on operands
+	// which are not integers in [-(2^53 - 1), 2^53 - 1], and the shifts fail
+	// on amounts outside [0, 63]. By default the operands are truncated
+	// towards zero, so 5.7 & 3 is 1.
+	StrictBitwise bool
+	// Write a "// from <location>" comment before each top-level field of
+	// the output, with the location of the code which defines its value, to
+	// find where a part of a large generated config comes from. The output
+	// is then not valid JSON, so this is only for debugging.
+	AnnotateFieldSources bool
+
+	ext         vmExtMap
+	nativeFuncs map[string]*NativeFunction
+	importer    Importer
+	ef          ErrorFormatter
+}
+
+// NativeFunction is a Go function which Jsonnet code can call after getting
+// it with std.native(name).
+type NativeFunction struct {
+	Name   string
+	Params ast.Identifiers
+	// BytesParams lists the parameters which Func receives as []byte. The
+	// Jsonnet arguments for them must be arrays of integers from 0 to 255.
+	BytesParams ast.Identifiers
+	// Func receives the arguments in the order of Params. Arguments which are
+	// not in BytesParams are converted to the types listed in ExtData. The
+	// result is converted back the same way, and a []byte becomes an array
+	// of numbers.
+	Func func(args []interface{}) (interface{}, error)
+}
+
+// Warning is a problem found in the code which, unlike an error, doesn't
+// prevent the evaluation, e.g. an unused local variable.
+type Warning struct {
+	Loc ast.LocationRange
+	Msg string
+}
+
+func (w Warning) String() string {
+	if w.Loc.IsSet() {
+		return fmt.Sprintf("%v WARNING: %v", w.Loc.String(), w.Msg)
+	}
+	return "WARNING: " + w.Msg
 }
 
 // TODO(sbarzowski) actually support these
@@ -45,6 +170,9 @@ type VM struct {
 type vmExt struct {
 	value  string // what is it?
 	isCode bool   // what is it?
+	// Go data bound with ExtData, used instead of value when isData is set.
+	data   interface{}
+	isData bool
 }
 
 type vmExtMap map[string]vmExt
@@ -52,10 +180,13 @@ type vmExtMap map[string]vmExt
 // MakeVM creates a new VM with default parameters.
 func MakeVM() *VM {
 	return &VM{
-		MaxStack: 500,
-		MaxTrace: 20,
-		ext:      make(vmExtMap),
-		ef:       ErrorFormatter{},
+		MaxStack:         500,
+		MaxTrace:         20,
+		MaxManifestDepth: 1000,
+		ext:              make(vmExtMap),
+		nativeFuncs:      make(map[string]*NativeFunction),
+		importer:         &FileImporter{},
+		ef:               ErrorFormatter{},
 	}
 }
 
@@ -69,36 +200,153 @@ func (vm *VM) ExtCode(key string, val string) {
 	vm.ext[key] = vmExt{value: val, isCode: true}
 }
 
-func (vm *VM) evaluateSnippet(filename string, snippet string) (output string, err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			err = fmt.Errorf("(CRASH) %v\n%s", r, debug.Stack())
+// NativeFunction registers a Go function, which Jsonnet code can get with
+// std.native(f.Name).
+func (vm *VM) NativeFunction(f *NativeFunction) {
+	vm.nativeFuncs[f.Name] = f
+}
+
+// Importer sets the importer used to resolve import and importstr.
+// By default files are loaded with a FileImporter.
+func (vm *VM) Importer(i Importer) {
+	vm.importer = i
+}
+
+// ExtData binds a Jsonnet external var to structured Go data, so it does not
+// have to be serialized first. The data must consist of the types that
+// encoding/json produces when unmarshalling into an interface{} (nil, bool,
+// float64, string, []interface{} and map[string]interface{}); float32, int,
+// int32 and int64 are accepted as numbers too, and []byte as an array of
+// numbers. The data must not be modified afterwards, otherwise evaluation may
+// fail with a conversion error.
+func (vm *VM) ExtData(key string, val interface{}) error {
+	if _, err := valueFromGo(val); err != nil {
+		return err
+	}
+	vm.ext[key] = vmExt{data: val, isData: true}
+	return nil
+}
+
+// recoverCrash turns a panic, i.e. a bug in the interpreter, into an error, so
+// that it doesn't bring down a program embedding the VM. In builds with the
+// jsonnet_debug tag the panic is let through with its original stack.
+func recoverCrash(err *error) {
+	if r := recover(); r != nil {
+		if debugPanics {
+			panic(r)
 		}
-	}()
-	node, err := snippetToAST(filename, snippet)
+		*err = fmt.Errorf("(CRASH) %v\n%s", r, debug.Stack())
+	}
+}
+
+func (vm *VM) evaluateSnippet(filename string, snippet string) (output string, err error) {
+	defer recoverCrash(&err)
+	node, err := vm.parseSnippet(filename, snippet)
 	if err != nil {
 		return "", err
 	}
-	output, err = evaluate(node, vm.ext, vm.MaxStack, &FileImporter{})
+	return vm.evaluateNode(node)
+}
+
+func (vm *VM) evaluateAST(node ast.Node) (output string, err error) {
+	defer recoverCrash(&err)
+	err = vm.prepareNode(&node)
+	if err != nil {
+		return "", err
+	}
+	return vm.evaluateNode(node)
+}
+
+// evaluateNode evaluates and manifests a desugared and analyzed AST.
+func (vm *VM) evaluateNode(node ast.Node) (output string, err error) {
+	if vm.MaxManifestDepth < 1 {
+		return "", fmt.Errorf("MaxManifestDepth must be at least 1, got %d", vm.MaxManifestDepth)
+	}
+	evalOpts := evaluationOptions{
+		negativeSliceIndices: vm.NegativeSliceIndices,
+		traceJSON:            vm.TraceJSON,
+		traceIndent:          vm.TraceIndent,
+		nativeFuncs:          vm.nativeFuncs,
+		strict:               vm.StrictEvaluation,
+		exactJSONIntegers:    vm.ExactJSONIntegers,
+		strictBitwise:        vm.StrictBitwise,
+		maxRangeLength:       vm.MaxRangeLength,
+	}
+	if !vm.DisableTrace {
+		evalOpts.traceOut = vm.TraceOut
+		if evalOpts.traceOut == nil {
+			evalOpts.traceOut = os.Stderr
+		}
+	}
+	escapeRune := vm.EscapeRune
+	if vm.EscapeHTML {
+		escapeRune = htmlEscapeRune(escapeRune)
+	}
+	manifestOpts := manifestationOptions{
+		maxDepth:        vm.MaxManifestDepth,
+		maxOutputSize:   vm.MaxOutputSize,
+		asciiOutput:     vm.ASCIIOutput,
+		upstreamNumbers: vm.UpstreamNumberFormat,
+		escapeRune:      escapeRune,
+		crlf:            vm.CRLFLineEndings,
+		bom:             vm.UTF8BOM,
+		annotateSources: vm.AnnotateFieldSources,
+	}
+	output, err = evaluate(node, vm.ext, vm.MaxStack, evalOpts, manifestOpts, vm.importer)
 	if err != nil {
 		return "", err
 	}
 	return output, nil
 }
 
+// formattedError is an error with a message prepared by the ErrorFormatter.
+// The original error (e.g. a RuntimeError) is available through Unwrap.
+type formattedError struct {
+	msg string
+	err error
+}
+
+func (err *formattedError) Error() string {
+	return err.msg
+}
+
+func (err *formattedError) Unwrap() error {
+	return err.err
+}
+
+// Evaluate evaluates a Jsonnet AST, e.g. one built or transformed by a tool,
+// and returns a JSON string. The node is desugared and statically checked
+// like parsed code first. This modifies the AST in place: the nodes below
+// the root are replaced by their desugared forms. The AST can be evaluated
+// again, but it no longer is the tree that was passed, so make a copy first
+// if the original is needed afterwards. Errors are formatted like in
+// EvaluateSnippet.
+func (vm *VM) Evaluate(node ast.Node) (json string, formattedErr error) {
+	if v := reflect.ValueOf(node); node == nil || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		return "", errors.New("Evaluate needs an AST, got nil")
+	}
+	json, err := vm.evaluateAST(node)
+	if err != nil {
+		return "", &formattedError{msg: vm.ef.format(err), err: err}
+	}
+	return json, nil
+}
+
 // EvaluateSnippet evaluates a string containing Jsonnet code, return a JSON
 // string.
 //
-// The filename parameter is only used for error messages.
+// The filename parameter is only used for error messages. The message of the
+// returned error is already formatted, including the stack trace. Use
+// errors.As to get the RuntimeError and inspect the stack trace directly.
 func (vm *VM) EvaluateSnippet(filename string, snippet string) (json string, formattedErr error) {
 	json, err := vm.evaluateSnippet(filename, snippet)
 	if err != nil {
-		return "", errors.New(vm.ef.format(err))
+		return "", &formattedError{msg: vm.ef.format(err), err: err}
 	}
 	return json, nil
 }
 
-func snippetToAST(filename string, snippet string) (ast.Node, error) {
+func desugaredSnippet(filename string, snippet string) (ast.Node, error) {
 	tokens, err := parser.Lex(filename, snippet)
 	if err != nil {
 		return nil, err
@@ -111,9 +359,58 @@ func snippetToAST(filename string, snippet string) (ast.Node, error) {
 	if err != nil {
 		return nil, err
 	}
+	return node, nil
+}
+
+func snippetToAST(filename string, snippet string) (ast.Node, error) {
+	node, err := desugaredSnippet(filename, snippet)
+	if err != nil {
+		return nil, err
+	}
 	err = analyze(node)
 	if err != nil {
 		return nil, err
 	}
 	return node, nil
 }
+
+// parseSnippet is like snippetToAST, but also runs the optional checks
+// enabled in the VM and reports their warnings.
+func (vm *VM) parseSnippet(filename string, snippet string) (ast.Node, error) {
+	tokens, err := parser.Lex(filename, snippet)
+	if err != nil {
+		return nil, err
+	}
+	node, err := parser.Parse(tokens)
+	if err != nil {
+		return nil, err
+	}
+	err = vm.prepareNode(&node)
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// prepareNode desugars and analyzes a parsed AST, running the optional checks
+// enabled in the VM and reporting their warnings.
+func (vm *VM) prepareNode(node *ast.Node) error {
+	err := desugarFile(node)
+	if err != nil {
+		return err
+	}
+	opts := &analysisOptions{checkTypes: vm.StaticTypeChecks}
+	if vm.WarnUnusedLocals {
+		opts.usage = newLocalUsage()
+	}
+	err = analyzeWithOptions(*node, opts)
+	if err != nil {
+		return err
+	}
+	if opts.usage != nil && vm.WarningHandler != nil {
+		for _, warning := range opts.usage.warnings() {
+			vm.WarningHandler(warning)
+		}
+	}
+	return nil
+}